@@ -1,5 +1,5 @@
 // @title           LestaStartTest API
-// @version         2.2.1
+// @version         3.0.0
 // @description     Сервис для загрузки документов, подсчёта TF‑IDF и управления коллекциями.
 // @schemes         http
 // @securityDefinitions.apikey BearerAuth
@@ -9,12 +9,17 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"strings"
 
 	"LestaStartTest/internal/controllers"
 	"LestaStartTest/internal/db"
 	"LestaStartTest/internal/middleware"
+	"LestaStartTest/internal/models"
+	"LestaStartTest/internal/ratelimit"
+	"LestaStartTest/internal/storage"
 
 	"LestaStartTest/docs"
 	_ "LestaStartTest/docs"
@@ -29,7 +34,11 @@ func init() {
 	if err := godotenv.Load(); err != nil {
 		log.Print("No .env file found")
 	}
+	middleware.InitKeys()
+	storage.Init()
+	ratelimit.Init()
 	db.Init()
+	controllers.BootstrapAdmin()
 }
 
 func main() {
@@ -40,6 +49,12 @@ func main() {
 	// Инициализация роутеров и запуск сервера
 	r := gin.Default()
 
+	// Адрес клиента (ограничения частоты, сессии) берётся из X-Forwarded-For только от доверенных прокси
+	trustedProxies := strings.FieldsFunc(os.Getenv("TRUSTED_PROXIES"), func(r rune) bool { return r == ',' || r == ' ' })
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// Раздаём папку static/ по корню
 	r.Static("/static", "./static")
 
@@ -55,47 +70,132 @@ func main() {
 		ginSwagger.URL("/swagger-docs/swagger.json"),
 	))
 
-	// Публичные API эндпоинты
-	r.POST("/login", controllers.LoginAPI)
-	r.POST("/register", controllers.RegisterAPI)
+	// Публичные API эндпоинты; вход и регистрация ограничены по частоте для одного IP, оба шага входа - общим лимитом
+	loginLimit := middleware.RateLimit("login", ratelimit.LimitFromEnv("LOGIN_RATE_LIMIT_IP", "20/m"))
+	r.POST("/login", loginLimit, controllers.LoginAPI)
+	r.POST("/login/2fa", loginLimit, controllers.LoginTwoFactorAPI)
+	r.POST("/register", middleware.RateLimit("register", ratelimit.LimitFromEnv("REGISTER_RATE_LIMIT_IP", "10/h")), controllers.RegisterAPI)
+	r.POST("/refresh", controllers.RefreshAPI)
+	r.GET("/.well-known/jwks.json", controllers.JWKSAPI)
+
+	// Публичные ссылки на коллекции: доступ по токену без аккаунта, только чтение
+	public := r.Group("/public")
+	public.GET("/collections/:token", controllers.PublicCollectionAPI)
+	public.GET("/collections/:token/statistics", controllers.PublicCollectionStatisticsAPI)
+	public.GET("/collections/:token/documents/:document_id", controllers.PublicDocumentAPI)
 
 	protected := r.Group("/api")
 	protected.Use(middleware.JWTAuth(), middleware.Handle401())
 	{
+		// Документы, коллекции и корзина активного пространства: личного или рабочего из заголовка X-Workspace-ID
+		content := protected.Group("", middleware.Workspace())
+
 		// Документы
-		protected.GET("/documents", controllers.ListDocumentsAPI)
-		protected.POST("/documents/upload", controllers.UploadAPI)
-		protected.GET("/documents/:id", controllers.GetDocumentAPI)
-		protected.GET("/documents/:id/statistics", controllers.DocumentStatisticsAPI)
-		protected.DELETE("/documents/:id", controllers.DeleteDocumentAPI)
-		protected.GET("/documents/:id/huffman", controllers.HuffmanEncodeAPI)
+		content.GET("/documents", controllers.ListDocumentsAPI)
+		content.POST("/documents/upload", controllers.UploadAPI)
+		content.GET("/documents/:id", controllers.GetDocumentAPI)
+		content.GET("/documents/:id/statistics", controllers.DocumentStatisticsAPI)
+		content.PUT("/documents/:id", controllers.UploadVersionAPI)
+		content.PATCH("/documents/:id", controllers.UpdateDocumentAPI)
+		content.DELETE("/documents/:id", controllers.DeleteDocumentAPI)
+		content.GET("/documents/:id/versions", controllers.ListVersionsAPI)
+		content.GET("/documents/:id/versions/:version", controllers.GetVersionAPI)
+		content.GET("/documents/:id/diff", controllers.DiffVersionsAPI)
+		content.GET("/documents/:id/huffman", controllers.HuffmanEncodeAPI)
+
+		// Возобновляемая загрузка по частям
+		content.POST("/uploads", controllers.CreateUploadAPI)
+		content.HEAD("/uploads/:id", controllers.UploadOffsetAPI)
+		content.PATCH("/uploads/:id", controllers.UploadChunkAPI)
+		content.POST("/uploads/:id/finalize", controllers.FinalizeUploadAPI)
+		content.DELETE("/uploads/:id", controllers.DeleteUploadAPI)
 
 		// Коллекции
-		protected.POST("/collections", controllers.CreateCollectionAPI)
-		protected.GET("/collections", controllers.ListCollectionsAPI)
-		protected.GET("/collections/:id", controllers.GetCollectionAPI)
-		protected.GET("/collections/:id/statistics", controllers.CollectionStatisticsAPI)
-		protected.POST("/collection/:collection_id/:document_id", controllers.AddDocumentToCollectionAPI)
-		protected.DELETE("/collection/:collection_id/:document_id", controllers.RemoveDocumentFromCollectionAPI)
-		protected.DELETE("/collections/:id", controllers.DeleteCollectionAPI)
-
-		// Пользователь
-		protected.PATCH("/user/:user_id", controllers.ChangePasswordAPI)
-		protected.DELETE("/user/:user_id", controllers.DeleteUserAPI)
+		content.POST("/collections", controllers.CreateCollectionAPI)
+		content.GET("/collections", controllers.ListCollectionsAPI)
+		content.GET("/collections/:id", controllers.GetCollectionAPI)
+		content.GET("/collections/:id/statistics", controllers.CollectionStatisticsAPI)
+		content.POST("/collections/:id/documents", controllers.AddDocumentsByTagsAPI)
+		content.POST("/collection/:collection_id/:document_id", controllers.AddDocumentToCollectionAPI)
+		content.DELETE("/collection/:collection_id/:document_id", controllers.RemoveDocumentFromCollectionAPI)
+		content.DELETE("/collections/:id", controllers.DeleteCollectionAPI)
+		content.POST("/collections/:id/shares", controllers.ShareCollectionAPI)
+		content.GET("/collections/:id/shares", controllers.ListCollectionSharesAPI)
+		content.DELETE("/collections/:id/shares/:user_id", controllers.DeleteCollectionShareAPI)
+		content.POST("/collections/:id/links", controllers.CreateShareLinkAPI)
+		content.GET("/collections/:id/links", controllers.ListShareLinksAPI)
+		content.DELETE("/collections/:id/links/:link_id", controllers.DeleteShareLinkAPI)
+
+		// Корзина
+		content.GET("/trash", controllers.TrashAPI)
+		content.POST("/trash/:type/:id/restore", controllers.RestoreAPI)
+
+		// Фоновые задачи
+		protected.GET("/jobs/:id", controllers.GetJobAPI)
+		protected.GET("/jobs/:id/events", controllers.JobEventsAPI)
+
+		// Рабочие пространства
+		protected.POST("/workspaces", controllers.CreateWorkspaceAPI)
+		protected.GET("/workspaces", controllers.ListWorkspacesAPI)
+		protected.GET("/workspaces/:id", controllers.GetWorkspaceAPI)
+		protected.PATCH("/workspaces/:id", controllers.UpdateWorkspaceAPI)
+		protected.DELETE("/workspaces/:id", controllers.DeleteWorkspaceAPI)
+		protected.POST("/workspaces/:id/members", controllers.AddWorkspaceMemberAPI)
+		protected.DELETE("/workspaces/:id/members/:user_id", controllers.DeleteWorkspaceMemberAPI)
+
+		// Пользователь; управление аккаунтом доступно API-ключам только с правами admin
+		protected.GET("/user/usage", controllers.UsageAPI)
+		account := protected.Group("/user", middleware.RequireScope(middleware.ScopeAdmin))
+		account.GET("/sessions", controllers.ListSessionsAPI)
+		account.DELETE("/sessions/:id", controllers.DeleteSessionAPI)
+		account.POST("/api-keys", controllers.CreateAPIKeyAPI)
+		account.GET("/api-keys", controllers.ListAPIKeysAPI)
+		account.DELETE("/api-keys/:id", controllers.DeleteAPIKeyAPI)
+		account.GET("/2fa", controllers.TwoFactorStatusAPI)
+		account.POST("/2fa", controllers.EnrollTwoFactorAPI)
+		account.POST("/2fa/confirm", controllers.ConfirmTwoFactorAPI)
+		account.DELETE("/2fa", controllers.DisableTwoFactorAPI)
+		account.POST("/2fa/recovery-codes", controllers.RegenerateRecoveryCodesAPI)
+		account.PATCH("/:user_id", controllers.ChangePasswordAPI)
+		account.DELETE("/:user_id", controllers.DeleteUserAPI)
+
+		// Администрирование; API-ключу нужны права admin
+		admin := protected.Group("/admin", middleware.RequireRole(models.RoleAdmin), middleware.RequireScope(middleware.ScopeAdmin))
+		admin.GET("/users", controllers.ListUsersAPI)
+		admin.PATCH("/users/:id", controllers.AdminUpdateUserAPI)
+		admin.DELETE("/users/:id", controllers.AdminDeleteUserAPI)
+		admin.POST("/users/:id/password", controllers.AdminResetPasswordAPI)
+		admin.DELETE("/users/:id/2fa", controllers.AdminResetTwoFactorAPI)
+		admin.GET("/users/:id/usage", controllers.AdminUsageAPI)
+		admin.DELETE("/documents/:id", controllers.AdminDeleteDocumentAPI)
+		admin.DELETE("/collections/:id", controllers.AdminDeleteCollectionAPI)
+		protected.GET("/metrics", middleware.RequireRole(models.RoleAdmin), controllers.MetricsHandler)
 
 		// Аутентификация (выход из аккаунта)
-		protected.GET("/logout", controllers.LogoutAPI)
+		protected.POST("/logout", controllers.LogoutAPI)
+		protected.GET("/logout", controllers.LogoutAPI) // прежний вариант без отзыва refresh-токена
 	}
 
 	// Системные эндпойнты
 	r.GET("/api/status", controllers.StatusHandler)
-	r.GET("/api/metrics", controllers.MetricsHandler)
 	r.GET("/api/version", controllers.VersionHandler)
 
 	r.NoRoute(func(c *gin.Context) {
 		c.File("./static/index.html")
 	})
 
+	// Очистка корзины от объектов старше TRASH_RETENTION
+	controllers.StartTrashPurge(context.Background())
+
+	// Удаление незавершённых сессий загрузки старше UPLOAD_SESSION_TTL
+	controllers.StartUploadSessionPurge(context.Background())
+
+	// Удаление истёкших refresh-токенов и отозванных access-токенов
+	controllers.StartTokenPurge(context.Background())
+
+	// Обработчики фоновых задач (загрузка файлов)
+	controllers.StartJobs(context.Background())
+
 	// Запуск сервера
 	r.Run(port)
 }
@@ -15,40 +15,62 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/collection/{collection_id}/{document_id}": {
-            "post": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Открытые ключи (JWKS, RFC 7517), которыми другие сервисы могут проверять access-токены.\nТокен указывает ключ в заголовке kid. При подписи HS256 (JWT_SECRET) список пуст.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Ключи проверки токенов",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/LestaStartTest_internal_keyring.JWKSet"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/collections/{id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Присоединяет документ к коллекции и обновляет IDF.",
+                "description": "Окончательно удаляет коллекцию любого пользователя, минуя корзину. Документы коллекции не удаляются.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Коллекции"
+                    "Администрирование"
                 ],
-                "summary": "Добавление документа в коллекцию",
+                "summary": "Принудительное удаление коллекции",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "ID коллекции",
-                        "name": "collection_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "ID документа",
-                        "name": "document_id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"message\":\"Document added to collection\"}",
+                        "description": "{\"message\":\"Collection deleted\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -57,7 +79,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Collection or Document not found",
+                        "description": "Collection not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -66,7 +88,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Failed to add document or update IDF",
+                        "description": "Delete failed",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -75,40 +97,35 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/admin/documents/{id}": {
             "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Убирает документ из коллекции и обновляет IDF.",
+                "description": "Окончательно удаляет документ любого пользователя, минуя корзину, вместе с версиями. IDF коллекций владельца пересчитывается.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Коллекции"
+                    "Администрирование"
                 ],
-                "summary": "Удаление документа из коллекции",
+                "summary": "Принудительное удаление документа",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "ID коллекции",
-                        "name": "collection_id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "integer",
                         "description": "ID документа",
-                        "name": "document_id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"message\":\"Document removed from collection\"}",
+                        "description": "{\"message\":\"Document deleted\"}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -116,8 +133,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Collection or Document not found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -125,43 +142,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Failed to remove document or update IDF",
+                    "404": {
+                        "description": "Document not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/api/collections": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Возвращает все коллекции пользователя.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Коллекции"
-                ],
-                "summary": "Список коллекций",
-                "responses": {
-                    "200": {
-                        "description": "{\"collections\":[]map[string]interface{}}",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
                     },
                     "500": {
-                        "description": "Database error",
+                        "description": "Delete failed",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -170,45 +161,90 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/api/admin/users": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Создаёт новую коллекцию для пользователя.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Возвращает страницу пользователей. Параметры страницы такие же, как у остальных списков.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Коллекции"
+                    "Администрирование"
                 ],
-                "summary": "Создание коллекции",
+                "summary": "Список пользователей",
                 "parameters": [
                     {
-                        "description": "Данные коллекции",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_controllers.CreateCollectionRequest"
-                        }
+                        "type": "integer",
+                        "description": "Размер страницы (1-200, по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка: username или created_at (по умолчанию); -поле - по убыванию",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поля через запятую: id, username, role, disabled, created_at",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя содержит (без учёта регистра)",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "user",
+                            "admin"
+                        ],
+                        "type": "string",
+                        "description": "Роль",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только заблокированные (true) или только активные (false)",
+                        "name": "disabled",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "{\"id\":int,\"name\":string}",
+                    "200": {
+                        "description": "{\"users\":[]AdminUserResponse,\"total\":int,\"next_cursor\":string}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid limit, cursor, sort, fields or filter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -217,7 +253,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Failed to create collection",
+                        "description": "Database error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -228,25 +264,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/collections/{id}": {
-            "get": {
+        "/api/admin/users/{id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает коллекцию и список её документов.",
+                "description": "Окончательно удаляет пользователя со всеми документами, коллекциями и ключами. Себя удаляют через DELETE /user/{user_id}.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Коллекции"
+                    "Администрирование"
                 ],
-                "summary": "Получение коллекции по ID",
+                "summary": "Удаление пользователя",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID коллекции",
+                        "description": "ID пользователя",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -254,14 +290,43 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"id\":int,\"name\":string,\"documents\":[]map[string]interface{}}",
+                        "description": "{\"message\":\"User deleted\"}",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "404": {
-                        "description": "Collection not found",
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Cannot delete yourself",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete user",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -271,32 +336,59 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "patch": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет коллекцию и все связанные IDF.",
+                "description": "Меняет роль пользователя и блокирует или разблокирует его. Заблокированный пользователь не может войти,\nего сессии завершаются, а токены и API-ключи перестают приниматься. Свою роль и блокировку менять нельзя.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Коллекции"
+                    "Администрирование"
                 ],
-                "summary": "Удаление коллекции",
+                "summary": "Изменение роли и блокировка пользователя",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID коллекции",
+                        "description": "ID пользователя",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Роль и блокировка",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.AdminUpdateUserRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"message\":\"Collection deleted\"}",
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.AdminUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -305,7 +397,16 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Collection not found",
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Cannot change own role or disable yourself",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -314,7 +415,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Failed to delete collection or related data",
+                        "description": "Database error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -325,25 +426,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/collections/{id}/statistics": {
-            "get": {
+        "/api/admin/users/{id}/2fa": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Рассчитывает TF‑IDF внутри всех документов коллекции.",
+                "description": "Отключает двухфакторную аутентификацию пользователя, потерявшего и приложение, и коды восстановления.\nКоды восстановления и незавершённые входы удаляются; пользователь сможет подключить её заново.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Коллекции"
+                    "Администрирование"
                 ],
-                "summary": "TF‑IDF статистика коллекции",
+                "summary": "Отключение двухфакторной аутентификации пользователя",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID коллекции",
+                        "description": "ID пользователя",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -351,14 +452,34 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"collection_id\":int,\"statistics\":map[string]object}",
+                        "description": "{\"message\":\"Two-factor authentication disabled\"}",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "404": {
-                        "description": "Collection not found",
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -369,31 +490,81 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/documents": {
-            "get": {
+        "/api/admin/users/{id}/password": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает все документы текущего пользователя.",
-                "produces": [
+                "description": "Устанавливает пользователю новый пароль, завершает все его сессии и снимает блокировку входа после неудачных попыток.\nПароль проверяется по тем же правилам, что при регистрации. API-ключи пользователя продолжают действовать.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Документы"
+                    "Администрирование"
+                ],
+                "summary": "Сброс пароля пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый пароль",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.ChangePasswordRequest"
+                        }
+                    }
                 ],
-                "summary": "Список документов",
                 "responses": {
                     "200": {
-                        "description": "{\"documents\":[]DocumentResponse}",
+                        "description": "{\"message\":\"Password reset\"}",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "500": {
-                        "description": "Database error",
+                        "description": "Password update failed",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -404,47 +575,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/documents/upload": {
-            "post": {
+        "/api/admin/users/{id}/usage": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Загружает один или несколько файлов, обрабатывает и сохраняет их.",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+                "description": "Возвращает потребление хранилища любым пользователем вместе с действующими ограничениями.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Документы"
+                    "Администрирование"
                 ],
-                "summary": "Загрузка файлов",
+                "summary": "Использование квоты пользователем",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "file"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Файлы для загрузки",
-                        "name": "files",
-                        "in": "formData",
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"message\":string,\"data\":UploadResult}",
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.UsageResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Error getting files or no files uploaded",
+                    "404": {
+                        "description": "User not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -453,48 +625,84 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "{\"errors\":[]string}",
+                        "description": "Database error",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/documents/{id}": {
-            "get": {
+        "/api/collection/{collection_id}/{document_id}": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает имя и содержимое документа по его ID.",
+                "description": "Присоединяет документ пользователя к коллекции и обновляет IDF. Требуется доступ owner или editor.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Документы"
+                    "Коллекции"
                 ],
-                "summary": "Получение документа",
+                "summary": "Добавление документа в коллекцию",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "collection_id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
                         "description": "ID документа",
-                        "name": "id",
+                        "name": "document_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "{\"message\":\"Document added to collection\"}",
                         "schema": {
-                            "$ref": "#/definitions/internal_controllers.DocumentResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Collection access is not enough",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "404": {
-                        "description": "Document not found",
+                        "description": "Collection or Document not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to add document or update IDF",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -510,26 +718,48 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет документ, все связи и физический файл.",
+                "description": "Убирает документ из коллекции и обновляет IDF. Владелец и редактор рабочего пространства убирают любые документы,\nредактор по совместному доступу - только документы своего пространства.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Документы"
+                    "Коллекции"
                 ],
-                "summary": "Удаление документа",
+                "summary": "Удаление документа из коллекции",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "collection_id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
                         "description": "ID документа",
-                        "name": "id",
+                        "name": "document_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"message\":\"Document deleted\"}",
+                        "description": "{\"message\":\"Document removed from collection\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Collection access is not enough",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -538,7 +768,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Document not found",
+                        "description": "Collection or Document not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -547,7 +777,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Failed to delete document",
+                        "description": "Failed to remove document or update IDF",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -558,49 +788,87 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/documents/{id}/huffman": {
+        "/api/collections": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает закодированное представление содержимого документа.",
+                "description": "Возвращает страницу коллекций пользователя и коллекций, к которым ему предоставлен доступ (shared=true). Следующая страница запрашивается с cursor из next_cursor и той же сортировкой.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Документы"
+                    "Коллекции"
                 ],
-                "summary": "Кодирование документа алгоритмом Хаффмана",
+                "summary": "Список коллекций",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID документа",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (1-200, по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка: name или created_at (по умолчанию); -поле - по убыванию",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поля коллекций через запятую: id, name, created_at, owner_id, workspace_id, shared, access",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "true - только чужие коллекции с доступом, false - только свои",
+                        "name": "shared",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя содержит (без учёта регистра)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Созданы не раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Созданы не позже (RFC 3339 или YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"document_id\":int,\"huffman_encoded\":string}",
+                        "description": "{\"collections\":[]map[string]interface{},\"total\":int,\"next_cursor\":string}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid document ID or content too large",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "404": {
-                        "description": "Document not found",
+                        "description": "Invalid limit, cursor, sort, fields or filter",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -609,7 +877,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Encoding failed",
+                        "description": "Database error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -618,51 +886,51 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/documents/{id}/statistics": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Рассчитывает TF‑IDF слова внутри всех коллекций, где есть этот документ.",
+                "description": "Создаёт новую коллекцию для пользователя.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Документы"
+                    "Коллекции"
                 ],
-                "summary": "TF‑IDF статистика документа",
+                "summary": "Создание коллекции",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID документа",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные коллекции",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.CreateCollectionRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "{\"document_id\":int,\"statistics\":map[string]object}",
+                    "201": {
+                        "description": "{\"id\":int,\"name\":string}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Document is not in any collection",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "404": {
-                        "description": "Document not found",
+                        "description": "Invalid request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -671,7 +939,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Failed to find collections",
+                        "description": "Failed to create collection",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -682,93 +950,79 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/logout": {
+        "/api/collections/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Завершает сессию пользователя (удаляет куки).",
+                "description": "Возвращает коллекцию и страницу её документов без содержимого, включая документы всех участников. Параметры страницы, сортировки, полей и фильтров документов\nтакие же, как у GET /api/documents.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Пользователь"
+                    "Коллекции"
                 ],
-                "summary": "Выход из аккаунта",
-                "responses": {
-                    "200": {
-                        "description": "{\"message\":\"Logged out\"}",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+                "summary": "Получение коллекции по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы документов (1-200, по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы документов",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка документов: name, created_at (по умолчанию) или size; -поле - по убыванию",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поля документов через запятую",
+                        "name": "fields",
+                        "in": "query"
                     }
-                }
-            }
-        },
-        "/api/metrics": {
-            "get": {
-                "description": "Возвращает общее число обработанных документов и среднее время обработки (нс).",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Системные"
                 ],
-                "summary": "Метрики обработки документов",
                 "responses": {
                     "200": {
-                        "description": "Application metrics",
+                        "description": "{\"id\":int,\"name\":string,\"created_at\":string,\"owner_id\":int,\"access\":string,\"documents\":[]DocumentResponse,\"total\":int,\"next_cursor\":string}",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
-                    }
-                }
-            }
-        },
-        "/api/status": {
-            "get": {
-                "description": "Проверяет, что сервис запущен.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Системные"
-                ],
-                "summary": "Статус приложения",
-                "responses": {
-                    "200": {
-                        "description": "Application is running",
+                    },
+                    "400": {
+                        "description": "Invalid limit, cursor, sort, fields or filter",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/api/version": {
-            "get": {
-                "description": "Возвращает значение переменной окружения VERSION.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Системные"
-                ],
-                "summary": "Текущая версия приложения",
-                "responses": {
-                    "200": {
-                        "description": "Application version",
+                    },
+                    "404": {
+                        "description": "Collection not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -777,42 +1031,48 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/login": {
-            "post": {
-                "description": "Проверяет учётные данные и возвращает JWT‑токен.",
-                "consumes": [
-                    "application/json"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Перемещает коллекцию в корзину и удаляет её IDF. Документы коллекции не удаляются.\nДоступно владельцу, коллекцию рабочего пространства удаляет и его редактор.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Пользователь"
+                    "Коллекции"
                 ],
-                "summary": "Аутентификация пользователя",
+                "summary": "Удаление коллекции",
                 "parameters": [
                     {
-                        "description": "Данные для аутентификации",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_controllers.AuthRequest"
-                        }
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"message\":string,\"token\":string}",
+                        "description": "{\"message\":\"Collection moved to trash\"}",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Invalid request body",
+                    "403": {
+                        "description": "Collection access is not enough",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -820,8 +1080,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Invalid credentials",
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete collection or related data",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -832,9 +1101,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
+        "/api/collections/{id}/documents": {
             "post": {
-                "description": "Создаёт нового пользователя и возвращает JWT‑токен.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет в коллекцию все документы пользователя, у которых есть все указанные теги, и один раз пересчитывает IDF.\nДокументы, которые уже состоят в коллекции, пропускаются. Требуется доступ owner или editor.",
                 "consumes": [
                     "application/json"
                 ],
@@ -842,23 +1116,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Пользователь"
+                    "Коллекции"
                 ],
-                "summary": "Регистрация пользователя",
+                "summary": "Добавление документов в коллекцию по тегам",
                 "parameters": [
                     {
-                        "description": "Данные для регистрации",
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Теги",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_controllers.AuthRequest"
+                            "$ref": "#/definitions/internal_controllers.AddDocumentsByTagsRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"message\":string,\"token\":string}",
+                        "description": "{\"added\":int,\"document_ids\":[]int}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -873,8 +1160,26 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "409": {
-                        "description": "User already exists",
+                    "403": {
+                        "description": "Collection access is not enough",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to add documents or update IDF",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -885,33 +1190,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/{user_id}": {
-            "delete": {
+        "/api/collections/{id}/links": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет пользователя, его документы и коллекции.",
+                "description": "Возвращает ссылки коллекции с правами и сроком действия. Сами токены не возвращаются.\nДоступно только владельцу; для коллекции рабочего пространства - только владельцу пространства, не редактору.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Пользователь"
+                    "Коллекции"
                 ],
-                "summary": "Удаление пользователя",
+                "summary": "Публичные ссылки коллекции",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID пользователя",
-                        "name": "user_id",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"message\":\"User deleted\"}",
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_controllers.ShareLinkResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Collection access is not enough",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -919,8 +1239,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -930,13 +1259,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "patch": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обновляет пароль текущего пользователя.",
+                "description": "Создаёт ссылку, по которой без аккаунта доступны коллекция, список её документов и TF-IDF статистика.\nТекст документов доступен, только если указано allow_content. Токен показывается один раз, сохраняется только его хэш.\nДоступно только владельцу; для коллекции рабочего пространства - только владельцу пространства, не редактору.",
                 "consumes": [
                     "application/json"
                 ],
@@ -944,30 +1273,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Пользователь"
+                    "Коллекции"
                 ],
-                "summary": "Изменение пароля пользователя",
+                "summary": "Создание публичной ссылки на коллекцию",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID пользователя",
-                        "name": "user_id",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Новый пароль",
+                        "description": "Доступ к тексту и срок действия",
                         "name": "body",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_controllers.ChangePasswordRequest"
+                            "$ref": "#/definitions/internal_controllers.CreateShareLinkRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "{\"message\":\"Password updated\"}",
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.CreateShareLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -975,8 +1315,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Invalid input",
+                    "403": {
+                        "description": "Collection access is not enough",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -984,8 +1324,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Too many share links",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -994,7 +1343,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Password update failed or database error",
+                        "description": "Database error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -1004,47 +1353,4263 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "internal_controllers.AuthRequest": {
-            "type": "object",
+        },
+        "/api/collections/{id}/links/{link_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет ссылку, запросы по ней сразу перестают приниматься.\nДоступно только владельцу; для коллекции рабочего пространства - только владельцу пространства, не редактору.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Коллекции"
+                ],
+                "summary": "Отзыв публичной ссылки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID ссылки",
+                        "name": "link_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"Share link revoked\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Collection access is not enough",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Collection or share link not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/collections/{id}/shares": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает пользователей, которым владелец предоставил доступ к коллекции. Доступно владельцу и участникам.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Коллекции"
+                ],
+                "summary": "Участники коллекции",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"owner_id\":int,\"shares\":[]CollectionShareResponse}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Даёт другому пользователю доступ к коллекции: viewer - просмотр документов и статистики,\neditor - также добавление в коллекцию своих документов. Повторный вызов меняет уровень доступа.\nДоступно только владельцу; для коллекции рабочего пространства - только владельцу пространства, не редактору.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Коллекции"
+                ],
+                "summary": "Предоставление доступа к коллекции",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Пользователь и уровень доступа",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.ShareCollectionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.CollectionShareResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Collection access is not enough",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Collection or user not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/collections/{id}/shares/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Владелец отзывает доступ любого участника, участник может выйти из коллекции сам.\nЛичные документы участника убираются из коллекции, документы рабочих пространств остаются; IDF пересчитывается.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Коллекции"
+                ],
+                "summary": "Отзыв доступа к коллекции",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID участника",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"Access revoked\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Only the owner can revoke access of other members",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Collection or share not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to revoke access or update IDF",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/collections/{id}/statistics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Рассчитывает TF‑IDF внутри всех документов коллекции, в том числе принадлежащих разным участникам.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Коллекции"
+                ],
+                "summary": "TF‑IDF статистика коллекции",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"collection_id\":int,\"statistics\":map[string]object}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Collection not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/documents": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу документов активного пространства (личного или рабочего из X-Workspace-ID) с метаданными, без содержимого.\nСледующая страница запрашивается с cursor из next_cursor и той же сортировкой. total - количество документов по фильтрам.\nПараметр tag можно указать несколько раз: тогда возвращаются документы со всеми указанными тегами.\nfrom и to ограничивают дату загрузки (RFC 3339 или YYYY-MM-DD; дата без времени в to включает весь день).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Список документов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (1-200, по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка: name, created_at (по умолчанию) или size; -поле - по убыванию",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поля документов через запятую: id, name, title, author, source_url, tags, fields, mime_type, size, version, created_at",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя содержит (без учёта регистра)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Тег документа",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Загружены не раньше",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Загружены не позже",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID коллекции, в которой состоят документы",
+                        "name": "in_collection",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.DocumentPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit, cursor, sort, fields or filter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/documents/upload": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Загружает один или несколько файлов, извлекает из них текст и сохраняет.\nПоддерживаются текстовые файлы, Markdown, HTML, RTF, DOCX, ODT и PDF с текстовым слоем.\nАрхивы zip, tar и tar.gz распаковываются, каждый файл архива становится отдельным документом.\nФайлы хранятся по SHA-256: повторно загруженное содержимое не сохраняется заново и помечается в ответе как duplicate.\nКоличество и размер файлов, а также суммарный объём и число документов пользователя ограничены квотами (см. GET /api/user/usage).\nФайлы обрабатываются в фоновой задаче: ответ содержит её ID, прогресс и итоговый UploadResult доступны в GET /api/jobs/{id}.\nВ UploadResult.files для каждого файла указан итог: stored, skipped (имя занято при on_conflict=reject или содержимое не изменилось при replace) или failed с причиной.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Загрузка файлов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "file"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Файлы для загрузки",
+                        "name": "files",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Кодировка файлов (UTF-8, UTF-16LE, UTF-16BE, windows-1251, KOI8-R, IBM866), по умолчанию определяется автоматически. Для уже загруженного ранее содержимого используется сохранённый текст",
+                        "name": "encoding",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Добавить файлы из архива в коллекцию с именем архива (создаётся при отсутствии)",
+                        "name": "archive_collection",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Всё или ничего: при ошибке любого файла или конфликте имён не сохраняется ни один файл. По умолчанию сохраняются все файлы, которые удалось обработать",
+                        "name": "atomic",
+                        "in": "formData"
+                    },
+                    {
+                        "enum": [
+                            "rename",
+                            "replace",
+                            "reject"
+                        ],
+                        "type": "string",
+                        "description": "Действие при совпадении имени с существующим документом: rename (по умолчанию), replace (новая версия существующего документа) или reject",
+                        "name": "on_conflict",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Задача создана, заголовок Location указывает на её статус",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.JobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Error getting files, no files uploaded, unknown encoding or on_conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Превышен лимит размера файла, количества файлов или квота пользователя",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to store files or create job",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/documents/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает имя, метаданные, извлечённый текст, исходную кодировку, MIME-тип и номер текущей версии документа по его ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Получение документа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID документа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.DocumentResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Загружает новое содержимое документа. ID, имя и членство в коллекциях сохраняются, IDF коллекций пересчитывается.\nЕсли содержимое совпадает с текущей версией, новая версия не создаётся и возвращается текущая.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Новая версия документа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID документа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Новое содержимое документа",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Кодировка файла (UTF-8, UTF-16LE, UTF-16BE, windows-1251, KOI8-R, IBM866), по умолчанию определяется автоматически",
+                        "name": "encoding",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Содержимое не изменилось",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.VersionResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.VersionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "No file uploaded, unknown encoding, archive or unsupported file type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Превышен лимит размера файла или квота пользователя",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to save version",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Перемещает документ в корзину. Членство в коллекциях сохраняется для восстановления,\nIDF коллекций пересчитывается без документа. Файлы удаляются после срока хранения корзины.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Удаление документа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID документа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"Document moved to trash\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete document",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Меняет имя, заголовок, автора, адрес источника, теги и дополнительные поля документа.\nПереданные поля заменяются, отсутствующие не меняются. tags заменяет все теги (теги приводятся к нижнему регистру),\nfields дополняет поля документа, значение null удаляет поле. Содержимое и версии не меняются.\nИмя очищается так же, как при загрузке; пустое после очистки имя отклоняется, занятое - 409.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Переименование и метаданные документа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID документа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменения",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.UpdateDocumentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.DocumentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Document with this name already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to update document",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/documents/{id}/diff": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Сравнивает TF слов двух версий документа. Слова с неизменным TF не возвращаются,\nостальные отсортированы по убыванию модуля изменения.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Разница TF между версиями",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID документа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Исходная версия (по умолчанию предпоследняя)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Сравниваемая версия (по умолчанию текущая)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"document_id\":int,\"from\":int,\"to\":int,\"terms\":[]calculation.TermDiff}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid version number",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Document or version not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/documents/{id}/huffman": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает закодированное представление содержимого документа.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Кодирование документа алгоритмом Хаффмана",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID документа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"document_id\":int,\"huffman_encoded\":string}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid document ID or content too large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Encoding failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/documents/{id}/statistics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Рассчитывает TF‑IDF слова внутри всех коллекций, где есть этот документ.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "TF‑IDF статистика документа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID документа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"document_id\":int,\"statistics\":map[string]object}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Document is not in any collection",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to find collections",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/documents/{id}/versions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает все версии документа, начиная с последней.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Версии документа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID документа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"document_id\":int,\"versions\":[]VersionResponse}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Document not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/documents/{id}/versions/{version}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает текст, кодировку и MIME-тип указанной версии документа.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Получение версии документа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID документа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер версии",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.DocumentResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Document or version not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/jobs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает статус, прогресс, ошибки отдельных файлов и результат фоновой задачи.\nЗадачи хранятся 7 дней после завершения (JOB_RETENTION).\nЕсли задача выполнена, но часть файлов не обработана, возвращается 207 Multi-Status: ошибки в errors, итог по каждому файлу в result.files.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Задачи"
+                ],
+                "summary": "Статус задачи",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.JobResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Задача выполнена частично",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.JobResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/jobs/{id}/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Поток Server-Sent Events с прогрессом задачи. Первое событие описывает текущее состояние.\nСобытия progress приходят после каждого файла (stage=files) и по мере пересчёта IDF коллекций (stage=idf),\nпоток закрывается после события completed или failed. Если задача выполняется другим экземпляром приложения,\nпрогресс читается из БД раз в JOB_EVENTS_POLL_INTERVAL (по умолчанию 2s).",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Задачи"
+                ],
+                "summary": "События задачи (SSE)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Поток событий progress, completed и failed",
+                        "schema": {
+                            "$ref": "#/definitions/LestaStartTest_internal_jobs.Event"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logout": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отзывает текущий access-токен и завершает его сессию вместе с refresh-токенами.\nДля токенов, выданных без сессии, refresh-токен можно передать в теле.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Выход из аккаунта",
+                "parameters": [
+                    {
+                        "description": "Refresh-токен этого входа",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"Logged out\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to revoke token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отзывает текущий access-токен и завершает его сессию вместе с refresh-токенами.\nДля токенов, выданных без сессии, refresh-токен можно передать в теле.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Выход из аккаунта",
+                "parameters": [
+                    {
+                        "description": "Refresh-токен этого входа",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"Logged out\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to revoke token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/metrics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает общее число обработанных документов и среднее время обработки (нс). Доступно администраторам.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Системные"
+                ],
+                "summary": "Метрики обработки документов",
+                "responses": {
+                    "200": {
+                        "description": "Application metrics",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/status": {
+            "get": {
+                "description": "Проверяет, что сервис запущен.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Системные"
+                ],
+                "summary": "Статус приложения",
+                "responses": {
+                    "200": {
+                        "description": "Application is running",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает удалённые документы и коллекции активного пространства, которые ещё можно восстановить.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Корзина"
+                ],
+                "summary": "Корзина",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"documents\":[]TrashItem,\"collections\":[]TrashItem}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/trash/{type}/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Восстанавливает документ или коллекцию. Документ возвращается во все коллекции, в которых состоял,\nи получает новое имя, если исходное уже занято. IDF затронутых коллекций пересчитывается.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Корзина"
+                ],
+                "summary": "Восстановление из корзины",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "documents",
+                            "collections"
+                        ],
+                        "type": "string",
+                        "description": "Тип объекта",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID документа или коллекции",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":string,\"id\":int,\"name\":string}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not found in trash",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to restore",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/uploads": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт сессию загрузки одного файла по частям (протокол в стиле tus). Размер файла передаётся в Upload-Length,\nпараметры - в Upload-Metadata: пары \"ключ base64(значение)\" через запятую. Обязателен ключ filename,\nнеобязательны encoding, on_conflict, archive_collection и atomic (как у POST /api/documents/upload).\nНезавершённая сессия удаляется через UPLOAD_SESSION_TTL (по умолчанию 24h) после получения последней части.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Начало возобновляемой загрузки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер файла в байтах",
+                        "name": "Upload-Length",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Например: filename cmVwb3J0LnR4dA==,on_conflict cmVwbGFjZQ==",
+                        "name": "Upload-Metadata",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "{\"id\":string,\"offset\":0,\"length\":int,\"expires_at\":string}, заголовок Location - адрес сессии",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Upload-Length or Upload-Metadata, unknown encoding or on_conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Превышен лимит размера файла или квота пользователя",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to create upload session",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/uploads/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет сессию и все полученные части.",
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Отмена возобновляемой загрузки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID сессии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сессия удалена"
+                    },
+                    "404": {
+                        "description": "Upload session not found or expired",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "head": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает в заголовке Upload-Offset, сколько байт уже получено. С этого смещения клиент продолжает загрузку после обрыва.",
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Смещение возобновляемой загрузки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID сессии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Заголовки Upload-Offset, Upload-Length и Upload-Expires"
+                    },
+                    "404": {
+                        "description": "Upload session not found or expired"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Дописывает тело запроса к файлу с указанного смещения. Upload-Offset должен совпадать с текущим смещением сессии,\nтело передаётся с Content-Type application/offset+octet-stream и обязательным Content-Length.\nЕсли часть получена не полностью, она не сохраняется и смещение не меняется.",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Часть возобновляемой загрузки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID сессии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение, с которого начинается часть",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Часть сохранена, новое смещение в заголовке Upload-Offset"
+                    },
+                    "400": {
+                        "description": "Invalid Upload-Offset",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Upload session not found or expired",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Upload-Offset does not match, текущее смещение в заголовке Upload-Offset",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "411": {
+                        "description": "Content-Length required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Chunk exceeds Upload-Length",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Content-Type must be application/offset+octet-stream",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to store chunk",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/uploads/{id}/finalize": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Передаёт полностью полученный файл в обычную обработку загрузок. Ответ такой же, как у POST /api/documents/upload:\nфоновая задача, статус которой доступен в GET /api/jobs/{id}. Сессия после этого удаляется.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Документы"
+                ],
+                "summary": "Завершение возобновляемой загрузки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства; без заголовка - личное пространство",
+                        "name": "X-Workspace-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID сессии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Задача создана, заголовок Location указывает на её статус",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.JobResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Upload session not found or expired",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Upload is incomplete",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to create job",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/2fa": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Включена ли двухфакторная аутентификация и сколько осталось неиспользованных кодов восстановления.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Состояние двухфакторной аутентификации",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.TwoFactorStatusResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт секрет TOTP (RFC 6238: SHA-1, 6 цифр, 30 секунд) и возвращает его вместе с адресом otpauth:// для QR-кода.\nВход начинает требовать код только после подтверждения (POST /api/user/2fa/confirm). Повторный вызов до подтверждения заменяет секрет.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Подключение двухфакторной аутентификации",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.TwoFactorSetupResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Two-factor authentication is already enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отключает двухфакторную аутентификацию после проверки текущего кода или кода восстановления. Коды восстановления удаляются.\nНеверные коды блокируют проверку кода в настройках учётной записи так же, как неудачный вход, но вход не блокируют.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Отключение двухфакторной аутентификации",
+                "parameters": [
+                    {
+                        "description": "Код из приложения или код восстановления",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"Two-factor authentication disabled\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid two-factor code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Two-factor authentication is not enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "{\"error\":string,\"retry_after\":int}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/2fa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Проверяет код из приложения-аутентификатора и включает двухфакторную аутентификацию.\nВ ответе - одноразовые коды восстановления, они показываются только один раз.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Подтверждение двухфакторной аутентификации",
+                "parameters": [
+                    {
+                        "description": "Код из приложения",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Two-factor setup not started",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Two-factor authentication is already enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/2fa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Заменяет все коды восстановления новыми после проверки текущего кода или кода восстановления.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Новые коды восстановления",
+                "parameters": [
+                    {
+                        "description": "Код из приложения или код восстановления",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid two-factor code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Two-factor authentication is not enabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "{\"error\":string,\"retry_after\":int}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает ключи пользователя с правами, сроком действия и временем последнего использования. Сами ключи не возвращаются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Список API-ключей",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_controllers.APIKeyResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт долгоживущий ключ для скриптов и CI. Ключ передаётся в заголовке \"Authorization: ApiKey \u003cключ\u003e\" или X-API-Key.\nПрава: read - только чтение, write - чтение и изменение документов и коллекций, admin - также управление аккаунтом.\nКлюч показывается один раз, сохраняется только его хэш.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Создание API-ключа",
+                "parameters": [
+                    {
+                        "description": "Имя, права и срок действия",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.CreateAPIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Too many API keys",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет ключ, запросы с ним сразу перестают приниматься.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Отзыв API-ключа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID ключа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"API key revoked\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает входы в аккаунт, которые ещё действуют: время входа и последнего обращения, IP и клиент.\nПоследнее обращение обновляется не чаще раза в минуту.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Активные сессии",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_controllers.SessionResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Завершает вход в аккаунт, например на потерянном устройстве: его access-токены перестают действовать сразу,\nrefresh-токены отзываются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Завершение сессии",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сессии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"Session revoked\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает суммарный размер всех версий документов пользователя и количество документов вместе с ограничениями.\nДокументы в корзине учитываются до окончательного удаления. Нулевой лимит означает отсутствие ограничения.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Использование квоты",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.UsageResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/version": {
+            "get": {
+                "description": "Возвращает значение переменной окружения VERSION.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Системные"
+                ],
+                "summary": "Текущая версия приложения",
+                "responses": {
+                    "200": {
+                        "description": "Application version",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает пространства, в которых состоит пользователь, и его роль в каждом.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Рабочие пространства"
+                ],
+                "summary": "Рабочие пространства пользователя",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_controllers.WorkspaceResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт рабочее пространство, создатель становится его владельцем. Документы и коллекции пространства\nдоступны всем участникам; пространство выбирается заголовком X-Workspace-ID в запросах к документам, коллекциям и корзине.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Рабочие пространства"
+                ],
+                "summary": "Создание рабочего пространства",
+                "parameters": [
+                    {
+                        "description": "Имя пространства",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.WorkspaceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.WorkspaceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает пространство, роль текущего пользователя и список участников. Доступно участникам.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Рабочие пространства"
+                ],
+                "summary": "Рабочее пространство и его участники",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"id\":int,\"name\":string,\"role\":string,\"created_at\":string,\"members\":[]WorkspaceMemberResponse}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Workspace not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Окончательно удаляет пространство со всеми его документами (включая корзину), коллекциями и незавершёнными загрузками.\nДоступно владельцам.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Рабочие пространства"
+                ],
+                "summary": "Удаление рабочего пространства",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"Workspace deleted\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Workspace role is not enough",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Workspace not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete workspace",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Меняет имя пространства. Доступно владельцам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Рабочие пространства"
+                ],
+                "summary": "Переименование рабочего пространства",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое имя",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.WorkspaceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.WorkspaceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Workspace role is not enough",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Workspace not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{id}/members": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет пользователя в пространство или меняет его роль: owner - управление участниками и пространством,\neditor - загрузка и изменение документов и коллекций, viewer - только чтение. Доступно владельцам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Рабочие пространства"
+                ],
+                "summary": "Добавление участника рабочего пространства",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Пользователь и роль",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.WorkspaceMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.WorkspaceMemberResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Workspace role is not enough",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Workspace or user not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Workspace must keep at least one owner",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{id}/members/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Владелец исключает любого участника, участник может выйти сам. Загруженные участником документы остаются в пространстве.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Рабочие пространства"
+                ],
+                "summary": "Исключение участника рабочего пространства",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID рабочего пространства",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID участника",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"Member removed\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Only owners can remove other members",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Workspace or member not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Workspace must keep at least one owner",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Проверяет учётные данные и возвращает короткоживущий JWT‑токен и refresh-токен для его обновления (POST /refresh).\nЧастота попыток ограничена для IP и для имени пользователя; после нескольких неудач подряд имя блокируется,\nи срок блокировки растёт с каждой следующей неудачей. При ограничении ответ 429 с заголовком Retry-After.\nЕсли у пользователя включена двухфакторная аутентификация, ответ 202 с токеном для второго шага (POST /login/2fa).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Аутентификация пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные для аутентификации",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.AuthRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.TokenResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.LoginChallengeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid credentials",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Account disabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "{\"error\":string,\"retry_after\":int}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/login/2fa": {
+            "post": {
+                "description": "Принимает токен первого шага (POST /login) и код из приложения-аутентификатора или одноразовый код восстановления,\nвозвращает пару токенов. Токен первого шага действует TWO_FACTOR_CHALLENGE_TTL и допускает 5 попыток;\nневерные коды учитываются в блокировке входа после неудачных попыток.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Второй шаг входа",
+                "parameters": [
+                    {
+                        "description": "Токен первого шага и код",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.TwoFactorLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired challenge or invalid code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Account disabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "{\"error\":string,\"retry_after\":int}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/public/collections/{token}": {
+            "get": {
+                "description": "Возвращает имя коллекции и страницу её документов без содержимого. Авторизация не нужна.\nПараметры страницы, сортировки, полей и фильтров документов такие же, как у GET /api/documents.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Публичные ссылки"
+                ],
+                "summary": "Коллекция по публичной ссылке",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен ссылки",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы документов (1-200, по умолчанию 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы документов",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка документов: name, created_at (по умолчанию) или size; -поле - по убыванию",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поля документов через запятую",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"name\":string,\"created_at\":string,\"allow_content\":bool,\"documents\":[]DocumentResponse,\"total\":int,\"next_cursor\":string}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit, cursor, sort, fields or filter",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Share link not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/public/collections/{token}/documents/{document_id}": {
+            "get": {
+                "description": "Возвращает документ коллекции с извлечённым текстом, если ссылка создана с allow_content. Авторизация не нужна.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Публичные ссылки"
+                ],
+                "summary": "Документ коллекции по публичной ссылке",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен ссылки",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID документа",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.DocumentResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Share link does not allow document content",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Share link or document not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/public/collections/{token}/statistics": {
+            "get": {
+                "description": "Рассчитывает TF‑IDF внутри всех документов коллекции, как GET /api/collections/{id}/statistics. Авторизация не нужна.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Публичные ссылки"
+                ],
+                "summary": "TF‑IDF статистика коллекции по публичной ссылке",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен ссылки",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"statistics\":map[string]object}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Share link not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "Обменивает refresh-токен на новую пару токенов. Каждый refresh-токен действует один раз:\nповторное предъявление уже обменянного токена считается кражей, и вся цепочка токенов этого входа отзывается.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Обновление токенов",
+                "parameters": [
+                    {
+                        "description": "Refresh-токен",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired refresh token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Token generation failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Создаёт нового пользователя и возвращает JWT‑токен и refresh-токен.\nИмя: 3-32 символа из латинских букв, цифр, '.', '_' и '-', без учёта регистра уникально.\nПароль: не короче PASSWORD_MIN_LENGTH символов (по умолчанию 8), не из списка распространённых и не содержит имя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Регистрация пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные для регистрации",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.AuthRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "User already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "{\"error\":string,\"retry_after\":int}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет пользователя, его документы и коллекции. Все токены пользователя перестают действовать.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Удаление пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"User deleted\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет пароль текущего пользователя и отзывает все его токены на всех устройствах.\nВ ответе - новая пара токенов для текущего клиента. Пароль проверяется по тем же правилам, что при регистрации.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователь"
+                ],
+                "summary": "Изменение пароля пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый пароль",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Password update failed or database error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "LestaStartTest_internal_jobs.Event": {
+            "type": "object",
+            "properties": {
+                "collection_id": {
+                    "description": "коллекция, IDF которой пересчитывается",
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "item": {
+                    "description": "обработанный файл",
+                    "type": "string"
+                },
+                "job_id": {
+                    "type": "integer"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "stage": {
+                    "type": "string",
+                    "enum": [
+                        "files",
+                        "idf"
+                    ]
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "progress",
+                        "completed",
+                        "failed"
+                    ]
+                }
+            }
+        },
+        "LestaStartTest_internal_keyring.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "description": "OKP",
+                    "type": "string"
+                },
+                "e": {
+                    "description": "RSA",
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "description": "RSA",
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "description": "OKP",
+                    "type": "string"
+                }
+            }
+        },
+        "LestaStartTest_internal_keyring.JWKSet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/LestaStartTest_internal_keyring.JWK"
+                    }
+                }
+            }
+        },
+        "LestaStartTest_internal_quota.Limits": {
+            "type": "object",
+            "properties": {
+                "max_bytes": {
+                    "description": "суммарный размер всех версий документов",
+                    "type": "integer"
+                },
+                "max_documents": {
+                    "description": "количество документов",
+                    "type": "integer"
+                },
+                "max_file_size": {
+                    "description": "размер одного файла",
+                    "type": "integer"
+                },
+                "max_files_per_request": {
+                    "description": "количество файлов в одном запросе",
+                    "type": "integer"
+                }
+            }
+        },
+        "LestaStartTest_internal_quota.Usage": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "documents": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_controllers.APIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "начало ключа",
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.AddDocumentsByTagsRequest": {
+            "type": "object",
+            "required": [
+                "tags"
+            ],
+            "properties": {
+                "tags": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "internal_controllers.AdminUpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "disabled": {
+                    "type": "boolean"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "internal_controllers.AdminUserResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "disabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.AuthRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.ChangePasswordRequest": {
+            "type": "object",
+            "properties": {
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.CollectionShareResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scope"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "по умолчанию через 90 дней",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scope": {
+                    "type": "string",
+                    "enum": [
+                        "read",
+                        "write",
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "internal_controllers.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "начало ключа",
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.CreateCollectionRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.CreateShareLinkRequest": {
+            "type": "object",
+            "properties": {
+                "allow_content": {
+                    "description": "разрешить получение текста документов",
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "description": "по умолчанию ссылка бессрочная",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.CreateShareLinkResponse": {
+            "type": "object",
+            "properties": {
+                "allow_content": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "prefix": {
+                    "description": "начало токена",
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.DocumentPage": {
+            "type": "object",
+            "properties": {
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_controllers.DocumentResponse"
+                    }
+                },
+                "next_cursor": {
+                    "description": "курсор следующей страницы, если она есть",
+                    "type": "string"
+                },
+                "total": {
+                    "description": "количество документов по фильтрам без учёта страниц",
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_controllers.DocumentResponse": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "encoding": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mime_type": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "размер исходного файла текущей версии",
+                    "type": "integer"
+                },
+                "source_url": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_controllers.JobResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "причина неудачи задачи",
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "ошибки отдельных файлов",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "result": {
+                    "description": "для загрузки - UploadResult",
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "queued",
+                        "running",
+                        "succeeded",
+                        "failed"
+                    ]
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.LoginChallengeResponse": {
+            "type": "object",
+            "properties": {
+                "challenge_token": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "срок действия challenge_token в секундах",
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "two_factor_required": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "internal_controllers.RecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "internal_controllers.RefreshRequest": {
+            "type": "object",
             "required": [
-                "password",
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.SessionResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "сессия, которой принадлежит токен запроса",
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "description": "когда сессия завершится, если не обновлять токены",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.ShareCollectionRequest": {
+            "type": "object",
+            "required": [
+                "role",
                 "username"
             ],
             "properties": {
-                "password": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "viewer",
+                        "editor"
+                    ]
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.ShareLinkResponse": {
+            "type": "object",
+            "properties": {
+                "allow_content": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "prefix": {
+                    "description": "начало токена",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "срок действия token в секундах",
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.TwoFactorCodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.TwoFactorLoginRequest": {
+            "type": "object",
+            "required": [
+                "challenge_token",
+                "code"
+            ],
+            "properties": {
+                "challenge_token": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.TwoFactorSetupResponse": {
+            "type": "object",
+            "properties": {
+                "otpauth_uri": {
+                    "description": "для QR-кода",
+                    "type": "string"
+                },
+                "secret": {
+                    "description": "base32, для ввода вручную",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.TwoFactorStatusResponse": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "recovery_codes_left": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_controllers.UpdateDocumentRequest": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "fields": {
+                    "description": "null удаляет поле, остальные поля документа сохраняются",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "source_url": {
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "заменяет все теги документа",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers.UsageResponse": {
+            "type": "object",
+            "properties": {
+                "limits": {
+                    "$ref": "#/definitions/LestaStartTest_internal_quota.Limits"
+                },
+                "usage": {
+                    "$ref": "#/definitions/LestaStartTest_internal_quota.Usage"
+                }
+            }
+        },
+        "internal_controllers.VersionResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
                     "type": "string"
                 },
+                "current": {
+                    "type": "boolean"
+                },
+                "encoding": {
+                    "type": "string"
+                },
+                "mime_type": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_controllers.WorkspaceMemberRequest": {
+            "type": "object",
+            "required": [
+                "role",
+                "username"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "editor",
+                        "viewer"
+                    ]
+                },
                 "username": {
                     "type": "string"
                 }
             }
         },
-        "internal_controllers.ChangePasswordRequest": {
+        "internal_controllers.WorkspaceMemberResponse": {
             "type": "object",
             "properties": {
-                "new_password": {
+                "created_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "internal_controllers.CreateCollectionRequest": {
+        "internal_controllers.WorkspaceRequest": {
             "type": "object",
             "required": [
                 "name"
             ],
             "properties": {
                 "name": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 100
                 }
             }
         },
-        "internal_controllers.DocumentResponse": {
+        "internal_controllers.WorkspaceResponse": {
             "type": "object",
             "properties": {
-                "content": {
+                "created_at": {
                     "type": "string"
                 },
                 "id": {
@@ -1052,6 +5617,9 @@ const docTemplate = `{
                 },
                 "name": {
                     "type": "string"
+                },
+                "role": {
+                    "type": "string"
                 }
             }
         }
@@ -1067,7 +5635,7 @@ const docTemplate = `{
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "2.2.1",
+	Version:          "3.0.0",
 	Host:             "",
 	BasePath:         "",
 	Schemes:          []string{"http"},
@@ -0,0 +1,204 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnsupported    = errors.New("unsupported archive format")
+	ErrTooManyEntries = errors.New("archive contains too many files")
+	ErrTooLarge       = errors.New("archive uncompressed size exceeds limit")
+	ErrUnsafePath     = errors.New("archive entry has unsafe path")
+)
+
+// Limits - ограничения на распаковку архива
+type Limits struct {
+	MaxEntries   int   // максимальное количество файлов
+	MaxTotalSize int64 // максимальный суммарный размер распакованных файлов в байтах
+}
+
+// Entry - файл, извлечённый из архива
+type Entry struct {
+	Name string // относительный путь внутри архива
+	Data []byte
+}
+
+// LimitsFromEnv - ограничения из переменных окружения ARCHIVE_MAX_ENTRIES и ARCHIVE_MAX_SIZE
+func LimitsFromEnv() Limits {
+	limits := Limits{MaxEntries: 1000, MaxTotalSize: 256 << 20}
+	if v, err := strconv.Atoi(os.Getenv("ARCHIVE_MAX_ENTRIES")); err == nil && v > 0 {
+		limits.MaxEntries = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("ARCHIVE_MAX_SIZE"), 10, 64); err == nil && v > 0 {
+		limits.MaxTotalSize = v
+	}
+	return limits
+}
+
+// BaseName - имя архива без расширений .zip, .tar, .tar.gz, .tgz
+func BaseName(filename string) string {
+	name := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip", ".gz"} {
+		if strings.HasSuffix(lower, ext) && len(name) > len(ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// Expand - распаковка zip, tar или tar.gz. Формат определяется по сигнатуре.
+// Каталоги, ссылки и служебные файлы пропускаются, пути с выходом за пределы архива отклоняются.
+// Сжатый gzip одиночный файл возвращается как один элемент с именем без .gz.
+func Expand(filename string, data []byte, limits Limits) ([]Entry, error) {
+	x := &expander{limits: limits}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return x.zip(data)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		// Запас на заголовки и выравнивание tar-блоков сверх лимита на содержимое файлов
+		overhead := int64(limits.MaxEntries+2) * 1024
+		inner, err := readLimited(gz, limits.MaxTotalSize+overhead)
+		if err != nil {
+			return nil, err
+		}
+		if isTar(inner) {
+			return x.tar(inner)
+		}
+		if int64(len(inner)) > limits.MaxTotalSize {
+			return nil, ErrTooLarge
+		}
+		name := strings.TrimSuffix(path.Base(strings.ReplaceAll(filename, "\\", "/")), ".gz")
+		return []Entry{{Name: name, Data: inner}}, nil
+	case isTar(data):
+		return x.tar(data)
+	}
+	return nil, ErrUnsupported
+}
+
+// isTar - проверка сигнатуры ustar в заголовке первого файла
+func isTar(data []byte) bool {
+	return len(data) >= 262 && bytes.Equal(data[257:262], []byte("ustar"))
+}
+
+type expander struct {
+	limits  Limits
+	total   int64
+	entries []Entry
+}
+
+// read - чтение с учётом оставшегося лимита распакованного размера.
+// Размеры из заголовков архива не используются, поэтому zip-бомбы с поддельными размерами тоже отсекаются.
+func (x *expander) read(r io.Reader) ([]byte, error) {
+	data, err := readLimited(r, x.limits.MaxTotalSize-x.total)
+	if err != nil {
+		return nil, err
+	}
+	x.total += int64(len(data))
+	return data, nil
+}
+
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+func (x *expander) add(name string, r io.Reader) error {
+	safe, skip, err := SafePath(name)
+	if err != nil || skip {
+		return err
+	}
+	if len(x.entries) >= x.limits.MaxEntries {
+		return ErrTooManyEntries
+	}
+	data, err := x.read(r)
+	if err != nil {
+		return err
+	}
+	x.entries = append(x.entries, Entry{Name: safe, Data: data})
+	return nil
+}
+
+func (x *expander) zip(data []byte) ([]Entry, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		err = x.add(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return x.entries, nil
+}
+
+func (x *expander) tar(data []byte) ([]Entry, error) {
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return x.entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := x.add(hdr.Name, tr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// SafePath - нормализация пути элемента архива (защита от zip-slip).
+// Возвращает skip=true для служебных файлов, которые не нужно загружать.
+func SafePath(name string) (string, bool, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") || (len(name) >= 2 && name[1] == ':') {
+		return "", false, fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", false, fmt.Errorf("%w: %s", ErrUnsafePath, name)
+		}
+	}
+
+	clean := path.Clean(name)
+	if clean == "." || strings.HasPrefix(clean, "__MACOSX/") || path.Base(clean) == ".DS_Store" {
+		return "", true, nil
+	}
+	return clean, false, nil
+}
@@ -0,0 +1,112 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildZip(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i+1 < len(files); i += 2 {
+		w, err := zw.Create(files[i])
+		require.NoError(t, err)
+		_, err = w.Write([]byte(files[i+1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func buildTarGz(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "corpus/", Typeflag: tar.TypeDir, Mode: 0755}))
+	for i := 0; i+1 < len(files); i += 2 {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: files[i], Mode: 0644, Size: int64(len(files[i+1]))}))
+		_, err := tw.Write([]byte(files[i+1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+var limits = Limits{MaxEntries: 10, MaxTotalSize: 1024}
+
+func TestExpandZip(t *testing.T) {
+	data := buildZip(t, "corpus/a.txt", "первый", "corpus/sub/b.txt", "второй", "__MACOSX/corpus/._a.txt", "мусор")
+
+	entries, err := Expand("corpus.zip", data, limits)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "corpus/a.txt", entries[0].Name)
+	assert.Equal(t, "corpus/sub/b.txt", entries[1].Name)
+	assert.Equal(t, "второй", string(entries[1].Data))
+}
+
+func TestExpandTarGz(t *testing.T) {
+	entries, err := Expand("corpus.tar.gz", buildTarGz(t, "corpus/a.txt", "первый", "corpus/b.txt", "второй"), limits)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "corpus/b.txt", entries[1].Name)
+	assert.Equal(t, "второй", string(entries[1].Data))
+}
+
+func TestExpandGzipSingleFile(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte("просто текст"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	entries, err := Expand("notes.txt.gz", buf.Bytes(), limits)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "notes.txt", entries[0].Name)
+}
+
+func TestExpandZipSlip(t *testing.T) {
+	for _, name := range []string{"../evil.txt", "corpus/../../evil.txt", "/etc/passwd", "C:\\evil.txt"} {
+		_, err := Expand("evil.zip", buildZip(t, name, "x"), limits)
+		assert.ErrorIs(t, err, ErrUnsafePath, name)
+	}
+}
+
+func TestExpandLimits(t *testing.T) {
+	files := make([]string, 0, 2*(limits.MaxEntries+1))
+	for i := 0; i <= limits.MaxEntries; i++ {
+		files = append(files, "f"+strings.Repeat("x", i)+".txt", "x")
+	}
+	_, err := Expand("many.zip", buildZip(t, files...), limits)
+	assert.ErrorIs(t, err, ErrTooManyEntries)
+
+	big := strings.Repeat("a", int(limits.MaxTotalSize)/2+1)
+	_, err = Expand("big.zip", buildZip(t, "a.txt", big, "b.txt", big), limits)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = Expand("big.tar.gz", buildTarGz(t, "a.txt", big, "b.txt", big), limits)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestExpandUnsupported(t *testing.T) {
+	_, err := Expand("file.txt", []byte("просто текст"), limits)
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestBaseName(t *testing.T) {
+	assert.Equal(t, "corpus", BaseName("corpus.zip"))
+	assert.Equal(t, "corpus", BaseName("dir/corpus.tar.gz"))
+	assert.Equal(t, "Отчёты 2024", BaseName("Отчёты 2024.TGZ"))
+	assert.Equal(t, ".zip", BaseName(".zip"))
+}
@@ -2,6 +2,7 @@ package calculation
 
 import (
 	"math"
+	"sort"
 	"strings"
 	"unicode"
 )
@@ -40,11 +41,16 @@ func CountTf(documents []string) map[string]float64 {
 
 // CountIdf - Вычисление IDF
 func CountIdf(documents []string) map[string]float64 {
+	return CountIdfProgress(documents, nil)
+}
+
+// CountIdfProgress - вычисление IDF с вызовом progress после обработки каждого документа
+func CountIdfProgress(documents []string, progress func(done int)) map[string]float64 {
 
 	documentsCount := len(documents)
 	wordsDocumentCount := make(map[string]int)
 
-	for _, doc := range documents {
+	for i, doc := range documents {
 		words := strings.Fields(doc)
 		seen := make(map[string]bool)
 		for _, word := range words {
@@ -53,6 +59,9 @@ func CountIdf(documents []string) map[string]float64 {
 				seen[word] = true
 			}
 		}
+		if progress != nil {
+			progress(i + 1)
+		}
 	}
 
 	idf := make(map[string]float64)
@@ -61,3 +70,39 @@ func CountIdf(documents []string) map[string]float64 {
 	}
 	return idf
 }
+
+// TermDiff - изменение TF слова между двумя текстами
+type TermDiff struct {
+	Word  string  `json:"word"`
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Delta float64 `json:"delta"`
+}
+
+// DiffTf - сравнение TF двух текстов. Слова с неизменным TF в результат не попадают,
+// остальные отсортированы по убыванию модуля изменения.
+func DiffTf(from, to string) []TermDiff {
+	tfFrom := CountTf([]string{from})
+	tfTo := CountTf([]string{to})
+
+	diff := make([]TermDiff, 0)
+	for word, f := range tfFrom {
+		if t := tfTo[word]; t != f {
+			diff = append(diff, TermDiff{Word: word, From: f, To: t, Delta: t - f})
+		}
+	}
+	for word, t := range tfTo {
+		if _, ok := tfFrom[word]; !ok {
+			diff = append(diff, TermDiff{Word: word, To: t, Delta: t})
+		}
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		di, dj := math.Abs(diff[i].Delta), math.Abs(diff[j].Delta)
+		if di != dj {
+			return di > dj
+		}
+		return diff[i].Word < diff[j].Word
+	})
+	return diff
+}
@@ -38,3 +38,33 @@ func TestCountIdf(t *testing.T) {
 	assert.True(t, idf["суслик"] > idf["ослик"], "Слово 'суслик' встречается реже, чем слово 'ослик'")
 	assert.True(t, idf["паукан"] > 0.0, "Слово 'паукан' должно быть больше нуля")
 }
+
+func TestCountIdfProgress(t *testing.T) {
+	documents := []string{"ослик суслик", "паукан ослик", "ослик"}
+
+	var calls []int
+	idf := CountIdfProgress(documents, func(done int) { calls = append(calls, done) })
+
+	assert.Equal(t, []int{1, 2, 3}, calls, "progress вызывается после каждого документа")
+	assert.Equal(t, CountIdf(documents), idf)
+}
+
+func TestDiffTf(t *testing.T) {
+	diff := DiffTf("ослик суслик суслик паукан", "ослик суслик паукан паукан")
+
+	// ослик не изменился (1/4), суслик 2/4 -> 1/4, паукан 1/4 -> 2/4
+	assert.Len(t, diff, 2)
+	assert.Equal(t, "паукан", diff[0].Word)
+	assert.InDelta(t, 0.25, diff[0].Delta, 0.0001)
+	assert.Equal(t, "суслик", diff[1].Word)
+	assert.InDelta(t, -0.25, diff[1].Delta, 0.0001)
+
+	diff = DiffTf("ослик", "суслик")
+	assert.Len(t, diff, 2)
+	for _, d := range diff {
+		if d.Word == "ослик" {
+			assert.InDelta(t, 1.0, d.From, 0.0001)
+			assert.InDelta(t, 0.0, d.To, 0.0001)
+		}
+	}
+}
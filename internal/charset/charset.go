@@ -0,0 +1,243 @@
+package charset
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	utf16 "golang.org/x/text/encoding/unicode"
+)
+
+// Поддерживаемые кодировки
+const (
+	UTF8        = "UTF-8"
+	UTF16LE     = "UTF-16LE"
+	UTF16BE     = "UTF-16BE"
+	Windows1251 = "windows-1251"
+	KOI8R       = "KOI8-R"
+	CP866       = "IBM866"
+)
+
+var (
+	ErrUndetectable    = errors.New("unable to detect character encoding")
+	ErrUnknownEncoding = errors.New("unknown character encoding")
+)
+
+// aliases - допустимые написания кодировок для ручного выбора
+var aliases = map[string]string{
+	"utf8":         UTF8,
+	"utf-8":        UTF8,
+	"utf16":        UTF16LE,
+	"utf-16":       UTF16LE,
+	"utf16le":      UTF16LE,
+	"utf-16le":     UTF16LE,
+	"utf16be":      UTF16BE,
+	"utf-16be":     UTF16BE,
+	"cp1251":       Windows1251,
+	"win1251":      Windows1251,
+	"windows1251":  Windows1251,
+	"windows-1251": Windows1251,
+	"koi8r":        KOI8R,
+	"koi8-r":       KOI8R,
+	"cp866":        CP866,
+	"ibm866":       CP866,
+	"866":          CP866,
+}
+
+// singleByte - однобайтовые кириллические кодировки, среди которых идёт выбор при автоопределении
+var singleByte = []struct {
+	name string
+	enc  encoding.Encoding
+}{
+	{Windows1251, charmap.Windows1251},
+	{KOI8R, charmap.KOI8R},
+	{CP866, charmap.CodePage866},
+}
+
+// letterFreq - частоты букв русского языка (в десятых долях процента)
+var letterFreq = map[rune]int{
+	'о': 110, 'е': 85, 'а': 80, 'и': 74, 'н': 67, 'т': 63, 'с': 55, 'р': 47,
+	'в': 45, 'л': 44, 'к': 35, 'м': 32, 'д': 30, 'п': 28, 'у': 26, 'я': 20,
+	'ы': 19, 'ь': 17, 'г': 17, 'з': 17, 'б': 16, 'ч': 14, 'й': 12, 'х': 10,
+	'ж': 9, 'ш': 7, 'ю': 6, 'ц': 5, 'щ': 4, 'э': 3, 'ф': 3, 'ъ': 1, 'ё': 1,
+}
+
+// Normalize - приведение названия кодировки к каноническому виду
+func Normalize(name string) (string, error) {
+	if enc, ok := aliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return enc, nil
+	}
+	return "", ErrUnknownEncoding
+}
+
+// Decode - перевод содержимого в UTF-8.
+// Если encoding пустая, кодировка определяется автоматически.
+// Возвращает текст и использованную кодировку.
+func Decode(data []byte, encoding string) (string, string, error) {
+	var err error
+	if encoding == "" {
+		encoding, err = Detect(data)
+	} else {
+		encoding, err = Normalize(encoding)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	var text string
+	switch encoding {
+	case UTF8:
+		text = strings.ToValidUTF8(string(bytes.TrimPrefix(data, bomUTF8)), string(utf8.RuneError))
+	case UTF16LE, UTF16BE:
+		endian := utf16.LittleEndian
+		if encoding == UTF16BE {
+			endian = utf16.BigEndian
+		}
+		text, err = decodeWith(utf16.UTF16(endian, utf16.UseBOM), data)
+	default:
+		for _, sb := range singleByte {
+			if sb.name == encoding {
+				text, err = decodeWith(sb.enc, data)
+			}
+		}
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	// PostgreSQL не принимает нулевые байты в колонках типа text
+	return strings.ReplaceAll(text, "\x00", ""), encoding, nil
+}
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// Detect - определение кодировки по BOM, распределению нулевых байтов и частотам кириллических букв
+func Detect(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return UTF8, nil
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return UTF16LE, nil
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return UTF16BE, nil
+	}
+
+	if enc, ok := detectUTF16(data); ok {
+		return enc, nil
+	}
+
+	// Нулевые байты вне UTF-16 - признак бинарного файла
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", ErrUndetectable
+	}
+
+	if utf8.Valid(data) {
+		return UTF8, nil
+	}
+
+	best, bestScore := "", 0
+	for _, sb := range singleByte {
+		text, err := decodeWith(sb.enc, data)
+		if err != nil {
+			continue
+		}
+		if score, ok := scoreCyrillic(text); ok && score > bestScore {
+			best, bestScore = sb.name, score
+		}
+	}
+	if best == "" {
+		return "", ErrUndetectable
+	}
+	return best, nil
+}
+
+// detectUTF16 - поиск UTF-16 без BOM: в латинице и кириллице один из байтов пары почти всегда нулевой или постоянный
+// (старший байт 0x04 у кириллицы), а другой меняется от символа к символу
+func detectUTF16(data []byte) (string, bool) {
+	pairs := len(data) / 2
+	if pairs < 2 || len(data)%2 != 0 {
+		return "", false
+	}
+
+	var even, odd [256]int
+	for i := 0; i+1 < len(data); i += 2 {
+		even[data[i]]++
+		odd[data[i+1]]++
+	}
+
+	switch {
+	case odd[0]*10 >= pairs*4 && even[0]*10 < pairs:
+		return UTF16LE, true
+	case even[0]*10 >= pairs*4 && odd[0]*10 < pairs:
+		return UTF16BE, true
+	case highBytes(&odd)*10 >= pairs*9 && highBytes(&even)*2 < pairs && even[0]*10 < pairs:
+		return UTF16LE, true
+	case highBytes(&even)*10 >= pairs*9 && highBytes(&odd)*2 < pairs && odd[0]*10 < pairs:
+		return UTF16BE, true
+	}
+	return "", false
+}
+
+// highBytes - сколько байтов похожи на старшие байты символов одного алфавита: нулевые (ASCII)
+// и самое частое значение из 0x01-0x1F (0x04 у кириллицы). В однобайтовых кодировках такие байты - управляющие символы.
+func highBytes(counts *[256]int) int {
+	top := 0
+	for b := 1; b < 0x20; b++ {
+		top = max(top, counts[b])
+	}
+	return counts[0] + top
+}
+
+// scoreCyrillic - оценка правдоподобия текста как русского.
+// Строчные буквы взвешиваются по частоте, прописные внутри слов и служебные символы штрафуются.
+func scoreCyrillic(text string) (int, bool) {
+	var score, letters, nonASCII int
+	prevLetter := false
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			prevLetter = unicode.IsLetter(r)
+			continue
+		}
+		nonASCII++
+
+		switch {
+		case r == utf8.RuneError || unicode.IsControl(r):
+			score -= 100
+		case unicode.Is(unicode.Cyrillic, r) && unicode.IsLower(r):
+			letters++
+			score += letterFreq[r]
+		case unicode.Is(unicode.Cyrillic, r) && unicode.IsUpper(r):
+			letters++
+			if prevLetter {
+				score -= 20
+			} else {
+				score += 5
+			}
+		default:
+			score -= 10
+		}
+		prevLetter = unicode.IsLetter(r)
+	}
+
+	// Большая часть байтов за пределами ASCII должна оказаться кириллическими буквами
+	if nonASCII == 0 || letters*10 < nonASCII*6 {
+		return 0, false
+	}
+	return score, score > 0
+}
+
+func decodeWith(enc encoding.Encoding, data []byte) (string, error) {
+	out, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,72 @@
+package charset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	utf16 "golang.org/x/text/encoding/unicode"
+)
+
+const sample = "Съешь же ещё этих мягких французских булок, да выпей чаю. Проверка кодировки документа."
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	out, err := enc.NewEncoder().Bytes([]byte(s))
+	require.NoError(t, err)
+	return out
+}
+
+func TestDetectAndDecode(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"utf-8", []byte(sample), UTF8},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, sample...), UTF8},
+		{"windows-1251", encode(t, charmap.Windows1251, sample), Windows1251},
+		{"koi8-r", encode(t, charmap.KOI8R, sample), KOI8R},
+		{"cp866", encode(t, charmap.CodePage866, sample), CP866},
+		{"utf-16le bom", encode(t, utf16.UTF16(utf16.LittleEndian, utf16.UseBOM), sample), UTF16LE},
+		{"utf-16be bom", encode(t, utf16.UTF16(utf16.BigEndian, utf16.UseBOM), sample), UTF16BE},
+		{"utf-16le без bom", encode(t, utf16.UTF16(utf16.LittleEndian, utf16.IgnoreBOM), "plain latin text"), UTF16LE},
+		{"utf-16le без bom, кириллица", encode(t, utf16.UTF16(utf16.LittleEndian, utf16.IgnoreBOM), sample), UTF16LE},
+		{"utf-16be без bom, кириллица", encode(t, utf16.UTF16(utf16.BigEndian, utf16.IgnoreBOM), sample), UTF16BE},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			detected, err := Detect(tc.data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, detected)
+
+			text, used, err := Decode(tc.data, "")
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, used)
+			assert.Contains(t, []string{sample, "plain latin text"}, text)
+		})
+	}
+}
+
+func TestDecodeOverride(t *testing.T) {
+	data := encode(t, charmap.KOI8R, sample)
+
+	text, used, err := Decode(data, "koi8r")
+	require.NoError(t, err)
+	assert.Equal(t, KOI8R, used)
+	assert.Equal(t, sample, text)
+
+	_, _, err = Decode(data, "ebcdic")
+	assert.ErrorIs(t, err, ErrUnknownEncoding)
+}
+
+func TestDetectBinary(t *testing.T) {
+	_, err := Detect([]byte{0x89, 'P', 'N', 'G', 0x00, 0x1A, 0xFF, 0x00, 0x13})
+	assert.ErrorIs(t, err, ErrUndetectable)
+
+	_, err = Detect([]byte{0xFF, 0x81, 0x83, 0x98, 0x9B, 0x9C})
+	assert.ErrorIs(t, err, ErrUndetectable)
+}
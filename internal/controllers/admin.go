@@ -0,0 +1,478 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"LestaStartTest/internal/credentials"
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/models"
+	"LestaStartTest/internal/pagination"
+	"LestaStartTest/internal/quota"
+	"LestaStartTest/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Сортировки и поля ответа списка пользователей
+var (
+	userSorts       = []string{"username", "created_at"}
+	userSortColumns = map[string]string{"username": "username", "created_at": "created_at"}
+	userFields      = []string{"id", "username", "role", "disabled", "created_at"}
+)
+
+// AdminUserResponse - пользователь в ответах администратора
+type AdminUserResponse struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AdminUpdateUserRequest - изменение роли и блокировки; отсутствующие поля не меняются
+type AdminUpdateUserRequest struct {
+	Role     *string `json:"role" binding:"omitempty,oneof=user admin"`
+	Disabled *bool   `json:"disabled"`
+}
+
+// ListUsersAPI – список пользователей
+// @Summary Список пользователей
+// @Description Возвращает страницу пользователей. Параметры страницы такие же, как у остальных списков.
+// @Tags Администрирование
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Размер страницы (1-200, по умолчанию 50)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param sort query string false "Сортировка: username или created_at (по умолчанию); -поле - по убыванию"
+// @Param fields query string false "Поля через запятую: id, username, role, disabled, created_at"
+// @Param username query string false "Имя содержит (без учёта регистра)"
+// @Param role query string false "Роль" Enums(user, admin)
+// @Param disabled query bool false "Только заблокированные (true) или только активные (false)"
+// @Success 200 {object} map[string]interface{} "{"users":[]AdminUserResponse,"total":int,"next_cursor":string}"
+// @Failure 400 {object} map[string]string "Invalid limit, cursor, sort, fields or filter"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/admin/users [get]
+func ListUsersAPI(c *gin.Context) {
+	params, err := parsePageParams(c.Query("sort"), c.Query("limit"), c.Query("cursor"), c.Query("fields"),
+		userSorts, pagination.Sort{Field: "created_at"}, userFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := db.DB.Model(&models.User{})
+	if username := c.Query("username"); username != "" {
+		query = query.Where(`LOWER(username) LIKE ? ESCAPE '\'`, containsPattern(username))
+	}
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	if disabled := c.Query("disabled"); disabled != "" {
+		value, err := strconv.ParseBool(disabled)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid disabled: use true or false"})
+			return
+		}
+		query = query.Where("disabled = ?", value)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	column := userSortColumns[params.sort.Field]
+	if params.cursor != nil {
+		var value interface{} = params.cursor.Value
+		if params.sort.Field == "created_at" {
+			if value, err = time.Parse(time.RFC3339Nano, params.cursor.Value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": pagination.ErrInvalidCursor.Error()})
+				return
+			}
+		}
+		query = afterCursor(query, column, "id", params.sort, value, params.cursor.ID)
+	}
+
+	var users []models.User
+	if err := orderBy(query, column, "id", params.sort).Limit(params.limit + 1).Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	response := gin.H{"total": total}
+	if len(users) > params.limit {
+		users = users[:params.limit]
+		last := users[len(users)-1]
+		value := last.Username
+		if params.sort.Field == "created_at" {
+			value = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+		}
+		response["next_cursor"] = pagination.Cursor{Sort: params.sort.String(), Value: value, ID: last.ID}.Encode()
+	}
+	items := make([]interface{}, len(users))
+	for i, user := range users {
+		items[i] = pickFields(adminUserResponse(user), params.fields)
+	}
+	response["users"] = items
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AdminUpdateUserAPI – роль и блокировка пользователя
+// @Summary Изменение роли и блокировка пользователя
+// @Description Меняет роль пользователя и блокирует или разблокирует его. Заблокированный пользователь не может войти,
+// @Description его сессии завершаются, а токены и API-ключи перестают приниматься. Свою роль и блокировку менять нельзя.
+// @Tags Администрирование
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param body body AdminUpdateUserRequest true "Роль и блокировка"
+// @Success 200 {object} AdminUserResponse
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "Cannot change own role or disable yourself"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/admin/users/{id} [patch]
+func AdminUpdateUserAPI(c *gin.Context) {
+	adminID := c.MustGet("userID").(uint)
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	var req AdminUpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	// Администратор не может случайно лишить доступа самого себя
+	if user.ID == adminID && (req.Role != nil && *req.Role != user.Role || req.Disabled != nil && *req.Disabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot change own role or disable yourself"})
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{}
+		if req.Role != nil {
+			updates["role"] = *req.Role
+			user.Role = *req.Role
+		}
+		if req.Disabled != nil {
+			updates["disabled"] = *req.Disabled
+			user.Disabled = *req.Disabled
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if req.Disabled != nil && *req.Disabled {
+			return revokeAllTokens(tx, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	log.Printf("Admin %d updated user %d: role=%s disabled=%t", adminID, user.ID, user.Role, user.Disabled)
+	c.JSON(http.StatusOK, adminUserResponse(user))
+}
+
+// AdminResetTwoFactorAPI – отключение двухфакторной аутентификации пользователя
+// @Summary Отключение двухфакторной аутентификации пользователя
+// @Description Отключает двухфакторную аутентификацию пользователя, потерявшего и приложение, и коды восстановления.
+// @Description Коды восстановления и незавершённые входы удаляются; пользователь сможет подключить её заново.
+// @Tags Администрирование
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]string "{"message":"Two-factor authentication disabled"}"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/admin/users/{id}/2fa [delete]
+func AdminResetTwoFactorAPI(c *gin.Context) {
+	adminID := c.MustGet("userID").(uint)
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	if err := resetTwoFactor(db.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	log.Printf("Admin %d disabled two-factor authentication of user %d", adminID, user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// AdminResetPasswordAPI – сброс пароля пользователя
+// @Summary Сброс пароля пользователя
+// @Description Устанавливает пользователю новый пароль, завершает все его сессии и снимает блокировку входа после неудачных попыток.
+// @Description Пароль проверяется по тем же правилам, что при регистрации. API-ключи пользователя продолжают действовать.
+// @Tags Администрирование
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param body body ChangePasswordRequest true "Новый пароль"
+// @Success 200 {object} map[string]string "{"message":"Password reset"}"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Password update failed"
+// @Router /api/admin/users/{id}/password [post]
+func AdminResetPasswordAPI(c *gin.Context) {
+	adminID := c.MustGet("userID").(uint)
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := credentials.PolicyFromEnv().CheckPassword(req.NewPassword, user.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password update failed"})
+		return
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		return revokeAllTokens(tx, user.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password update failed"})
+		return
+	}
+
+	// Сброс пароля снимает и блокировку входа после неудачных попыток
+	if err := ratelimit.LockoutFromEnv().Success(c.Request.Context(), loginLockoutKey(user.Username)); err != nil {
+		log.Printf("Lockout reset failed: %v", err)
+	}
+
+	log.Printf("Admin %d reset password of user %d", adminID, user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset"})
+}
+
+// AdminUsageAPI – потребление пользователя
+// @Summary Использование квоты пользователем
+// @Description Возвращает потребление хранилища любым пользователем вместе с действующими ограничениями.
+// @Tags Администрирование
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} UsageResponse
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/admin/users/{id}/usage [get]
+func AdminUsageAPI(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	usage, err := userUsage(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, UsageResponse{Usage: usage, Limits: quota.LimitsFromEnv()})
+}
+
+// AdminDeleteUserAPI – удаление пользователя администратором
+// @Summary Удаление пользователя
+// @Description Окончательно удаляет пользователя со всеми документами, коллекциями и ключами. Себя удаляют через DELETE /user/{user_id}.
+// @Tags Администрирование
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]string "{"message":"User deleted"}"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "Cannot delete yourself"
+// @Failure 500 {object} map[string]string "Failed to delete user"
+// @Router /api/admin/users/{id} [delete]
+func AdminDeleteUserAPI(c *gin.Context) {
+	adminID := c.MustGet("userID").(uint)
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+	if user.ID == adminID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete yourself, use DELETE /api/user/{user_id}"})
+		return
+	}
+
+	if err := deleteUser(c.Request.Context(), user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+
+	log.Printf("Admin %d deleted user %d (%s)", adminID, user.ID, user.Username)
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// AdminDeleteDocumentAPI – принудительное удаление документа
+// @Summary Принудительное удаление документа
+// @Description Окончательно удаляет документ любого пользователя, минуя корзину, вместе с версиями. IDF коллекций владельца пересчитывается.
+// @Tags Администрирование
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID документа"
+// @Success 200 {object} map[string]string "{"message":"Document deleted"}"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 500 {object} map[string]string "Delete failed"
+// @Router /api/admin/documents/{id} [delete]
+func AdminDeleteDocumentAPI(c *gin.Context) {
+	adminID := c.MustGet("userID").(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var document models.Document
+	if err := db.DB.Unscoped().First(&document, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+	collectionIDs, err := documentCollectionIDs([]uint{document.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var staleKeys []string
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		staleKeys, err = purgeDocuments(tx, []uint{document.ID})
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Delete failed"})
+		return
+	}
+	db.RemoveBlobFiles(c.Request.Context(), staleKeys)
+	if err := recalcCollections(c.Request.Context(), collectionIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update IDF"})
+		return
+	}
+
+	log.Printf("Admin %d deleted document %d of user %d", adminID, document.ID, document.UserID)
+	c.JSON(http.StatusOK, gin.H{"message": "Document deleted"})
+}
+
+// AdminDeleteCollectionAPI – принудительное удаление коллекции
+// @Summary Принудительное удаление коллекции
+// @Description Окончательно удаляет коллекцию любого пользователя, минуя корзину. Документы коллекции не удаляются.
+// @Tags Администрирование
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID коллекции"
+// @Success 200 {object} map[string]string "{"message":"Collection deleted"}"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Collection not found"
+// @Failure 500 {object} map[string]string "Delete failed"
+// @Router /api/admin/collections/{id} [delete]
+func AdminDeleteCollectionAPI(c *gin.Context) {
+	adminID := c.MustGet("userID").(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var collection models.Collection
+	if err := db.DB.Unscoped().First(&collection, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if err := db.DB.Transaction(func(tx *gorm.DB) error {
+		return purgeCollections(tx, []uint{collection.ID})
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Delete failed"})
+		return
+	}
+
+	log.Printf("Admin %d deleted collection %d of user %d", adminID, collection.ID, collection.UserID)
+	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted"})
+}
+
+// BootstrapAdmin - назначение администратора из ADMIN_USERNAME при запуске. Если пользователя нет
+// и задан ADMIN_PASSWORD, он создаётся. Так появляется первый администратор, остальных назначают через API.
+func BootstrapAdmin() {
+	username := os.Getenv("ADMIN_USERNAME")
+	if username == "" {
+		return
+	}
+
+	var user models.User
+	err := db.DB.Where("username = ?", username).First(&user).Error
+	switch {
+	case err == nil:
+		if user.Role == models.RoleAdmin {
+			return
+		}
+		err = db.DB.Model(&user).Update("role", models.RoleAdmin).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		password := os.Getenv("ADMIN_PASSWORD")
+		if password == "" {
+			log.Printf("Admin user %q not found and ADMIN_PASSWORD is not set, skipping", username)
+			return
+		}
+		var hashed []byte
+		if hashed, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost); err == nil {
+			err = db.DB.Create(&models.User{Username: username, Password: string(hashed), Role: models.RoleAdmin}).Error
+		}
+	}
+	if err != nil {
+		log.Fatalf("Failed to bootstrap admin %q: %v", username, err)
+	}
+	log.Printf("User %q is admin", username)
+}
+
+// findUser - пользователь из параметра id; при ошибке ответ уже отправлен
+func findUser(c *gin.Context) (models.User, bool) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var user models.User
+	if err := db.DB.First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		}
+		return user, false
+	}
+	return user, true
+}
+
+func adminUserResponse(user models.User) AdminUserResponse {
+	return AdminUserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		Disabled:  user.Disabled,
+		CreatedAt: user.CreatedAt,
+	}
+}
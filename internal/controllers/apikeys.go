@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/middleware"
+	"LestaStartTest/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Срок действия API-ключа по умолчанию и ограничение количества ключей пользователя
+const (
+	defaultAPIKeyTTL  = 90 * 24 * time.Hour
+	maxAPIKeysPerUser = 50
+)
+
+// CreateAPIKeyRequest - параметры нового API-ключа
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scope     string     `json:"scope" binding:"required,oneof=read write admin"`
+	ExpiresAt *time.Time `json:"expires_at"` // по умолчанию через 90 дней
+}
+
+// APIKeyResponse - API-ключ без секрета
+type APIKeyResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // начало ключа
+	Scope      string     `json:"scope"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAPIKeyResponse - созданный ключ; key возвращается только в этом ответе
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyAPI – создание API-ключа
+// @Summary Создание API-ключа
+// @Description Создаёт долгоживущий ключ для скриптов и CI. Ключ передаётся в заголовке "Authorization: ApiKey <ключ>" или X-API-Key.
+// @Description Права: read - только чтение, write - чтение и изменение документов и коллекций, admin - также управление аккаунтом.
+// @Description Ключ показывается один раз, сохраняется только его хэш.
+// @Tags Пользователь
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body CreateAPIKeyRequest true "Имя, права и срок действия"
+// @Success 201 {object} CreateAPIKeyResponse
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 409 {object} map[string]string "Too many API keys"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/user/api-keys [post]
+func CreateAPIKeyAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	expiresAt := time.Now().Add(defaultAPIKeyTTL)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+			return
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	var count int64
+	if err := db.DB.Model(&models.APIKey{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if count >= maxAPIKeysPerUser {
+		c.JSON(http.StatusConflict, gin.H{"error": "Too many API keys, revoke unused ones first"})
+		return
+	}
+
+	key := middleware.APIKeyPrefix + randomToken(32, base64.RawURLEncoding.EncodeToString)
+	apiKey := models.APIKey{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    key[:len(middleware.APIKeyPrefix)+8],
+		KeyHash:   middleware.HashAPIKey(key),
+		Scope:     req.Scope,
+		ExpiresAt: expiresAt,
+	}
+	if err := db.DB.Create(&apiKey).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{APIKeyResponse: apiKeyResponse(apiKey), Key: key})
+}
+
+// ListAPIKeysAPI – API-ключи пользователя
+// @Summary Список API-ключей
+// @Description Возвращает ключи пользователя с правами, сроком действия и временем последнего использования. Сами ключи не возвращаются.
+// @Tags Пользователь
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} APIKeyResponse
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/user/api-keys [get]
+func ListAPIKeysAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var apiKeys []models.APIKey
+	if err := db.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&apiKeys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	response := make([]APIKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		response[i] = apiKeyResponse(apiKey)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteAPIKeyAPI – отзыв API-ключа
+// @Summary Отзыв API-ключа
+// @Description Удаляет ключ, запросы с ним сразу перестают приниматься.
+// @Tags Пользователь
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID ключа"
+// @Success 200 {object} map[string]string "{"message":"API key revoked"}"
+// @Failure 404 {object} map[string]string "API key not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/user/api-keys/{id} [delete]
+func DeleteAPIKeyAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	res := db.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.APIKey{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+func apiKeyResponse(apiKey models.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scope:      apiKey.Scope,
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
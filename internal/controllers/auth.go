@@ -1,15 +1,39 @@
 package controllers
 
 import (
+	"LestaStartTest/internal/credentials"
 	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/keyring"
 	"LestaStartTest/internal/middleware"
 	"LestaStartTest/internal/models"
+	"LestaStartTest/internal/ratelimit"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+// Срок действия refresh-токена и период удаления истёкших токенов по умолчанию
+const (
+	defaultRefreshTokenTTL    = 30 * 24 * time.Hour
+	defaultTokenPurgeInterval = time.Hour
+)
+
+// Ограничение попыток входа для одного имени пользователя по умолчанию (LOGIN_RATE_LIMIT_USERNAME)
+const defaultLoginUsernameLimit = "10/m"
+
+var errRefreshTokenReused = errors.New("refresh token reused")
+
 // Структуры запросов
 
 type AuthRequest struct {
@@ -22,16 +46,35 @@ type AuthResponse struct {
 	UserID  uint   `json:"user_id,omitempty"`
 }
 
+// TokenResponse - пара токенов: короткоживущий access-токен для заголовка Authorization и refresh-токен для его обновления
+type TokenResponse struct {
+	Message      string `json:"message,omitempty"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // срок действия token в секундах
+}
+
+// RefreshRequest - refresh-токен для обмена или отзыва
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 // LoginAPI – аутентификация пользователя
 // @Summary Аутентификация пользователя
-// @Description Проверяет учётные данные и возвращает JWT‑токен.
+// @Description Проверяет учётные данные и возвращает короткоживущий JWT‑токен и refresh-токен для его обновления (POST /refresh).
+// @Description Частота попыток ограничена для IP и для имени пользователя; после нескольких неудач подряд имя блокируется,
+// @Description и срок блокировки растёт с каждой следующей неудачей. При ограничении ответ 429 с заголовком Retry-After.
+// @Description Если у пользователя включена двухфакторная аутентификация, ответ 202 с токеном для второго шага (POST /login/2fa).
 // @Tags Пользователь
 // @Accept json
 // @Produce json
 // @Param body body controllers.AuthRequest true "Данные для аутентификации"
-// @Success 200 {object} map[string]interface{} "{"message":string,"token":string}"
+// @Success 200 {object} TokenResponse
+// @Success 202 {object} LoginChallengeResponse
 // @Failure 400 {object} map[string]string "Invalid request body"
 // @Failure 401 {object} map[string]string "Invalid credentials"
+// @Failure 403 {object} map[string]string "Account disabled"
+// @Failure 429 {object} map[string]interface{} "{"error":string,"retry_after":int}"
 // @Router /login [post]
 func LoginAPI(c *gin.Context) {
 	var req AuthRequest
@@ -40,38 +83,91 @@ func LoginAPI(c *gin.Context) {
 		return
 	}
 
+	// Ограничения привязаны к имени, а не к учётной записи: несуществующее имя ограничивается так же
+	ctx := c.Request.Context()
+	key := loginLockoutKey(req.Username)
+	limit := ratelimit.LimitFromEnv("LOGIN_RATE_LIMIT_USERNAME", defaultLoginUsernameLimit)
+	if ok, wait, err := ratelimit.Default.Allow(ctx, "login:user:"+key, limit); err != nil {
+		log.Printf("Rate limit check failed: %v", err)
+	} else if !ok {
+		middleware.AbortTooManyRequests(c, wait, "Too many login attempts for this user, try again later")
+		return
+	}
+	lockout := ratelimit.LockoutFromEnv()
+	if left, err := lockout.Check(ctx, key); err != nil {
+		log.Printf("Lockout check failed: %v", err)
+	} else if left > 0 {
+		middleware.AbortTooManyRequests(c, left, "Too many failed login attempts, try again later")
+		return
+	}
+
 	var user models.User
 	if err := db.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials (login or password)"})
+		loginFailed(c, lockout, key)
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials (login or password)"})
+		loginFailed(c, lockout, key)
+		return
+	}
+	if user.Disabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
 		return
 	}
 
-	token, err := middleware.GenerateToken(user.ID)
+	enabled, err := twoFactorEnabled(db.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if enabled {
+		challenge, err := startLoginChallenge(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Token generation failed"})
+			return
+		}
+		c.JSON(http.StatusAccepted, challenge)
+		return
+	}
+	// При двухфакторной аутентификации счётчик неудач сбрасывается только после проверки кода,
+	// иначе повторный ввод пароля обнулял бы неверные попытки подбора кода
+	if err := lockout.Success(ctx, key); err != nil {
+		log.Printf("Lockout reset failed: %v", err)
+	}
+
+	tokens, err := startSession(c, db.DB, user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token generation failed"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Logged successfully",
-		"token":   token,
-	})
+	tokens.Message = "Logged successfully"
+	c.JSON(http.StatusOK, tokens)
+}
+
+// loginFailed - ответ на неверные учётные данные; неудача учитывается для блокировки имени
+func loginFailed(c *gin.Context, lockout ratelimit.Lockout, key string) {
+	if d, err := lockout.Fail(c.Request.Context(), key); err != nil {
+		log.Printf("Lockout update failed: %v", err)
+	} else if d > 0 {
+		log.Printf("Login for %q locked for %s after failed attempts", key, d)
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials (login or password)"})
 }
 
 // RegisterAPI – регистрация нового пользователя
 // @Summary Регистрация пользователя
-// @Description Создаёт нового пользователя и возвращает JWT‑токен.
+// @Description Создаёт нового пользователя и возвращает JWT‑токен и refresh-токен.
+// @Description Имя: 3-32 символа из латинских букв, цифр, '.', '_' и '-', без учёта регистра уникально.
+// @Description Пароль: не короче PASSWORD_MIN_LENGTH символов (по умолчанию 8), не из списка распространённых и не содержит имя.
 // @Tags Пользователь
 // @Accept json
 // @Produce json
 // @Param body body controllers.AuthRequest true "Данные для регистрации"
-// @Success 200 {object} map[string]interface{} "{"message":string,"token":string}"
+// @Success 200 {object} TokenResponse
 // @Failure 400 {object} map[string]string "Invalid request"
 // @Failure 409 {object} map[string]string "User already exists"
+// @Failure 429 {object} map[string]interface{} "{"error":string,"retry_after":int}"
 // @Router /register [post]
 func RegisterAPI(c *gin.Context) {
 	var req AuthRequest
@@ -79,10 +175,18 @@ func RegisterAPI(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
+	if err := credentials.CheckUsername(req.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := credentials.PolicyFromEnv().CheckPassword(req.Password, req.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// Проверка на существование пользователя
+	// Проверка на существование пользователя; имена, различающиеся только регистром, считаются одним
 	var count int64
-	db.DB.Model(&models.User{}).Where("username = ?", req.Username).Count(&count)
+	db.DB.Model(&models.User{}).Where("LOWER(username) = ?", strings.ToLower(req.Username)).Count(&count)
 	if count > 0 {
 		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
 		return
@@ -105,25 +209,222 @@ func RegisterAPI(c *gin.Context) {
 		return
 	}
 
-	token, err := middleware.GenerateToken(user.ID)
+	tokens, err := startSession(c, db.DB, user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token generation failed"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"message": "User successfully registered",
-		"token":   token,
+	tokens.Message = "User successfully registered"
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RefreshAPI – обновление токенов
+// @Summary Обновление токенов
+// @Description Обменивает refresh-токен на новую пару токенов. Каждый refresh-токен действует один раз:
+// @Description повторное предъявление уже обменянного токена считается кражей, и вся цепочка токенов этого входа отзывается.
+// @Tags Пользователь
+// @Accept json
+// @Produce json
+// @Param body body controllers.RefreshRequest true "Refresh-токен"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 401 {object} map[string]string "Invalid or expired refresh token"
+// @Failure 500 {object} map[string]string "Token generation failed"
+// @Router /refresh [post]
+func RefreshAPI(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	var tokens TokenResponse
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		if err := tx.Where("token_hash = ?", hashToken(req.RefreshToken)).First(&stored).Error; err != nil {
+			return err
+		}
+		if stored.ExpiresAt.Before(time.Now()) {
+			return gorm.ErrRecordNotFound
+		}
+
+		// Токен помечается использованным условно, чтобы два параллельных обмена не получили две пары
+		res := tx.Model(&stored).Where("used_at IS NULL").Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errRefreshTokenReused
+		}
+
+		if err := renewSession(c, tx, stored.UserID, stored.Family); err != nil {
+			return err
+		}
+		var err error
+		tokens, err = issueTokens(tx, stored.UserID, stored.Family)
+		return err
 	})
+	if errors.Is(err, errRefreshTokenReused) {
+		// Цепочка отзывается вне отменённой транзакции
+		revokeReusedFamily(req.RefreshToken)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected, please log in again"})
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token generation failed"})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
 }
 
 // LogoutAPI – выход из аккаунта
 // @Summary Выход из аккаунта
-// @Description Завершает сессию пользователя (удаляет куки).
+// @Description Отзывает текущий access-токен и завершает его сессию вместе с refresh-токенами.
+// @Description Для токенов, выданных без сессии, refresh-токен можно передать в теле.
 // @Tags Пользователь
 // @Security BearerAuth
+// @Accept json
 // @Produce json
+// @Param body body controllers.RefreshRequest false "Refresh-токен этого входа"
 // @Success 200 {object} map[string]string "{"message":"Logged out"}"
+// @Failure 500 {object} map[string]string "Failed to revoke token"
+// @Router /api/logout [post]
 // @Router /api/logout [get]
 func LogoutAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	claims := c.MustGet("claims").(*middleware.Claims)
+
+	if err := middleware.RevokeToken(claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+
+	sessionID := claims.SessionID
+	var req RefreshRequest
+	if sessionID == "" && c.ShouldBindJSON(&req) == nil {
+		var stored models.RefreshToken
+		if err := db.DB.Where("token_hash = ? AND user_id = ?", hashToken(req.RefreshToken), userID).First(&stored).Error; err == nil {
+			sessionID = stored.Family
+		}
+	}
+	if sessionID != "" {
+		if _, err := endSession(db.DB, userID, sessionID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
 }
+
+// JWKSAPI – открытые ключи подписи токенов
+// @Summary Ключи проверки токенов
+// @Description Открытые ключи (JWKS, RFC 7517), которыми другие сервисы могут проверять access-токены.
+// @Description Токен указывает ключ в заголовке kid. При подписи HS256 (JWT_SECRET) список пуст.
+// @Tags Пользователь
+// @Produce json
+// @Success 200 {object} keyring.JWKSet
+// @Router /.well-known/jwks.json [get]
+func JWKSAPI(c *gin.Context) {
+	var set keyring.JWKSet = middleware.JWKS()
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
+
+// StartTokenPurge - фоновое удаление истёкших сессий, refresh-токенов, незавершённых входов и записей об отозванных access-токенах
+func StartTokenPurge(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(defaultTokenPurgeInterval)
+		defer ticker.Stop()
+		for {
+			if err := PurgeTokens(time.Now()); err != nil {
+				log.Printf("Token purge failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// PurgeTokens - удаление токенов и незавершённых входов, истёкших к моменту now
+func PurgeTokens(now time.Time) error {
+	if err := db.DB.Where("expires_at < ?", now).Delete(&models.Session{}).Error; err != nil {
+		return err
+	}
+	if err := db.DB.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error; err != nil {
+		return err
+	}
+	if err := db.DB.Where("expires_at < ?", now).Delete(&models.LoginChallenge{}).Error; err != nil {
+		return err
+	}
+	return db.DB.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error
+}
+
+// issueTokens - новая пара токенов сессии; refresh-токены сессии образуют одну цепочку
+func issueTokens(tx *gorm.DB, userID uint, sessionID string) (TokenResponse, error) {
+	access, err := middleware.GenerateToken(userID, sessionID)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	refresh := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err := tx.Create(&models.RefreshToken{
+		UserID:    userID,
+		TokenHash: hashToken(refresh),
+		Family:    sessionID,
+		ExpiresAt: time.Now().Add(refreshTokenTTL()),
+	}).Error; err != nil {
+		return TokenResponse{}, err
+	}
+
+	return TokenResponse{
+		Token:        access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(middleware.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// revokeAllTokens - отзыв всех токенов пользователя: access-токены, выданные раньше текущей секунды, все сессии и refresh-токены
+func revokeAllTokens(tx *gorm.DB, userID uint) error {
+	now := time.Now().Truncate(time.Second)
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("tokens_valid_after", now).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.Session{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&models.RefreshToken{}).Error
+}
+
+// revokeReusedFamily - завершение сессии, в которой повторно предъявлен уже обменянный refresh-токен
+func revokeReusedFamily(token string) {
+	var stored models.RefreshToken
+	if err := db.DB.Where("token_hash = ?", hashToken(token)).First(&stored).Error; err != nil {
+		return
+	}
+	endSession(db.DB, stored.UserID, stored.Family)
+	log.Printf("Refresh token reuse detected for user %d, session revoked", stored.UserID)
+}
+
+// refreshTokenTTL - срок действия refresh-токена и сессии без обращений из REFRESH_TOKEN_TTL (по умолчанию 30 дней)
+func refreshTokenTTL() time.Duration {
+	return durationFromEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(size int, encode func([]byte) string) string {
+	b := make([]byte, size)
+	rand.Read(b)
+	return encode(b)
+}
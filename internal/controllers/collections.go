@@ -3,19 +3,30 @@ package controllers
 import (
 	"LestaStartTest/internal/calculation"
 	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/jobs"
+	"LestaStartTest/internal/metadata"
 	"LestaStartTest/internal/models"
+	"LestaStartTest/internal/pagination"
+	"context"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CreateCollectionRequest struct {
 	Name string `json:"name" binding:"required"`
 }
 
+// AddDocumentsByTagsRequest - теги документов, добавляемых в коллекцию
+type AddDocumentsByTagsRequest struct {
+	Tags []string `json:"tags" binding:"required,min=1"`
+}
+
 // CreateCollectionAPI – создание коллекции
 // @Summary Создание коллекции
 // @Description Создаёт новую коллекцию для пользователя.
@@ -23,13 +34,14 @@ type CreateCollectionRequest struct {
 // @Security BearerAuth
 // @Accept json
 // @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
 // @Param body body controllers.CreateCollectionRequest true "Данные коллекции"
 // @Success 201 {object} map[string]interface{} "{"id":int,"name":string}"
 // @Failure 400 {object} map[string]string "Invalid request"
 // @Failure 500 {object} map[string]string "Failed to create collection"
 // @Router /api/collections [post]
 func CreateCollectionAPI(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
+	scope := requestScope(c)
 
 	var req CreateCollectionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -38,8 +50,9 @@ func CreateCollectionAPI(c *gin.Context) {
 	}
 
 	collection := models.Collection{
-		UserID: userID,
-		Name:   req.Name,
+		UserID:      scope.UserID,
+		WorkspaceID: scope.WorkspaceID,
+		Name:        req.Name,
 	}
 
 	if err := db.DB.Create(&collection).Error; err != nil {
@@ -53,18 +66,21 @@ func CreateCollectionAPI(c *gin.Context) {
 	})
 }
 
-// recalcCollectionIDF - пересчет IDF для коллекции
-func recalcCollectionIDF(collectionID uint, userID uint) error {
+// recalcCollectionIDF - пересчет IDF для коллекции. Внутри фоновой задачи сообщает о прогрессе по документам.
+func recalcCollectionIDF(ctx context.Context, collectionID uint) error {
 	var texts []string
 	err := db.DB.Table("documents").
-		Select("processed_content").
 		Joins("JOIN collection_documents cd ON cd.document_id = documents.id").
-		Where("cd.collection_id = ? AND documents.user_id = ?", collectionID, userID).
-		Pluck("processed_content", &texts).Error
+		Joins("JOIN blobs ON blobs.hash = documents.blob_hash").
+		Where("cd.collection_id = ? AND documents.deleted_at IS NULL", collectionID).
+		Pluck("blobs.processed_content", &texts).Error
 	if err != nil {
 		return err
 	}
-	idfMap := calculation.CountIdf(texts)
+	run := jobs.FromContext(ctx)
+	idfMap := calculation.CountIdfProgress(texts, func(done int) {
+		run.Progress(jobs.Event{Stage: jobs.StageIDF, CollectionID: collectionID, Processed: done, Total: len(texts)})
+	})
 
 	tx := db.DB.Begin()
 	tx.Where("collection_id = ?", collectionID).Delete(&models.CollectionIDF{})
@@ -74,104 +90,284 @@ func recalcCollectionIDF(collectionID uint, userID uint) error {
 	return tx.Commit().Error
 }
 
+// recalcDocumentCollections - пересчёт IDF всех коллекций, в которых состоят документы
+func recalcDocumentCollections(ctx context.Context, documentIDs []uint) error {
+	collectionIDs, err := documentCollectionIDs(documentIDs)
+	if err != nil {
+		return err
+	}
+	return recalcCollections(ctx, collectionIDs)
+}
+
+// documentCollectionIDs - коллекции, в которых состоят документы
+func documentCollectionIDs(documentIDs []uint) ([]uint, error) {
+	var collectionIDs []uint
+	err := db.DB.Table("collection_documents").
+		Distinct("collection_id").
+		Where("document_id IN ?", documentIDs).
+		Pluck("collection_id", &collectionIDs).Error
+	return collectionIDs, err
+}
+
+// recalcCollections - пересчёт IDF коллекций
+func recalcCollections(ctx context.Context, collectionIDs []uint) error {
+	for _, id := range collectionIDs {
+		if err := recalcCollectionIDF(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addToNamedCollection - добавление документов в коллекцию с заданным именем (создаётся при отсутствии) и пересчёт IDF
+func addToNamedCollection(ctx context.Context, scope ownerScope, name string, docs []*models.Document) (models.Collection, error) {
+	collection := models.Collection{UserID: scope.UserID, WorkspaceID: scope.WorkspaceID, Name: name}
+	if err := scope.where(db.DB, "collections").Where("name = ?", name).
+		FirstOrCreate(&collection).Error; err != nil {
+		return collection, err
+	}
+
+	if err := db.DB.Model(&collection).Association("Documents").Append(docs); err != nil {
+		return collection, err
+	}
+
+	return collection, recalcCollectionIDF(ctx, collection.ID)
+}
+
+// Сортировки и поля ответа списка коллекций
+var (
+	collectionSorts       = []string{"name", "created_at"}
+	collectionSortColumns = map[string]string{"name": "name", "created_at": "created_at"}
+	collectionFields      = []string{"id", "name", "created_at", "owner_id", "workspace_id", "shared", "access"}
+)
+
 // ListCollectionsAPI – список коллекций
 // @Summary Список коллекций
-// @Description Возвращает все коллекции пользователя.
+// @Description Возвращает страницу коллекций пользователя и коллекций, к которым ему предоставлен доступ (shared=true). Следующая страница запрашивается с cursor из next_cursor и той же сортировкой.
 // @Tags Коллекции
 // @Security BearerAuth
 // @Produce json
-// @Success 200 {object} map[string]interface{} "{"collections":[]map[string]interface{}}"
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param limit query int false "Размер страницы (1-200, по умолчанию 50)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param sort query string false "Сортировка: name или created_at (по умолчанию); -поле - по убыванию"
+// @Param fields query string false "Поля коллекций через запятую: id, name, created_at, owner_id, workspace_id, shared, access"
+// @Param shared query bool false "true - только чужие коллекции с доступом, false - только свои"
+// @Param name query string false "Имя содержит (без учёта регистра)"
+// @Param from query string false "Созданы не раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param to query string false "Созданы не позже (RFC 3339 или YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "{"collections":[]map[string]interface{},"total":int,"next_cursor":string}"
+// @Failure 400 {object} map[string]string "Invalid limit, cursor, sort, fields or filter"
 // @Failure 500 {object} map[string]string "Database error"
 // @Router /api/collections [get]
 func ListCollectionsAPI(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
+	scope := requestScope(c)
+
+	params, err := parsePageParams(c.Query("sort"), c.Query("limit"), c.Query("cursor"), c.Query("fields"),
+		collectionSorts, pagination.Sort{Field: "created_at"}, collectionFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Коллекции активного пространства и коллекции, к которым пользователю предоставлен доступ
+	owned, ownedArgs := scope.condition("collections")
+	sharedWithUser := "collections.id IN (SELECT collection_id FROM collection_shares WHERE user_id = ?)"
+	query := db.DB.Model(&models.Collection{})
+	switch c.Query("shared") {
+	case "":
+		query = query.Where("("+owned+" OR "+sharedWithUser+")", append(ownedArgs, scope.UserID)...)
+	case "true":
+		query = query.Where(sharedWithUser, scope.UserID).Where("NOT COALESCE(("+owned+"), FALSE)", ownedArgs...)
+	case "false":
+		query = query.Where(owned, ownedArgs...)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shared: use true or false"})
+		return
+	}
+	if name := c.Query("name"); name != "" {
+		query = query.Where(`LOWER(name) LIKE ? ESCAPE '\'`, containsPattern(name))
+	}
+	if from := c.Query("from"); from != "" {
+		t, err := parseDate(from, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from: use RFC 3339 or YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("created_at >= ?", t)
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := parseDate(to, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to: use RFC 3339 or YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("created_at < ?", t)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	column := collectionSortColumns[params.sort.Field]
+	if params.cursor != nil {
+		var value interface{} = params.cursor.Value
+		if params.sort.Field == "created_at" {
+			if value, err = time.Parse(time.RFC3339Nano, params.cursor.Value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": pagination.ErrInvalidCursor.Error()})
+				return
+			}
+		}
+		query = afterCursor(query, column, "id", params.sort, value, params.cursor.ID)
+	}
 
 	var collections []models.Collection
-	if err := db.DB.Where("user_id = ?", userID).Find(&collections).Error; err != nil {
+	if err := orderBy(query, column, "id", params.sort).Limit(params.limit + 1).Find(&collections).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
-	response := make([]gin.H, len(collections))
+	response := gin.H{"total": total}
+	if len(collections) > params.limit {
+		collections = collections[:params.limit]
+		last := collections[len(collections)-1]
+		value := last.Name
+		if params.sort.Field == "created_at" {
+			value = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+		}
+		response["next_cursor"] = pagination.Cursor{Sort: params.sort.String(), Value: value, ID: last.ID}.Encode()
+	}
+	sharedIDs := make([]uint, 0, len(collections))
+	for _, col := range collections {
+		if !scope.owns(col.UserID, col.WorkspaceID) {
+			sharedIDs = append(sharedIDs, col.ID)
+		}
+	}
+	access := make(map[uint]string, len(sharedIDs))
+	if len(sharedIDs) > 0 {
+		var shares []models.CollectionShare
+		if err := db.DB.Where("user_id = ? AND collection_id IN ?", scope.UserID, sharedIDs).Find(&shares).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		for _, share := range shares {
+			access[share.CollectionID] = share.Role
+		}
+	}
+
+	items := make([]interface{}, len(collections))
 	for i, col := range collections {
-		response[i] = gin.H{
-			"id":   col.ID,
-			"name": col.Name,
+		shared := !scope.owns(col.UserID, col.WorkspaceID)
+		colAccess := scope.collectionAccess()
+		if shared {
+			colAccess = access[col.ID]
 		}
+		items[i] = pickFields(gin.H{
+			"id":           col.ID,
+			"name":         col.Name,
+			"created_at":   col.CreatedAt,
+			"owner_id":     col.UserID,
+			"workspace_id": col.WorkspaceID,
+			"shared":       shared,
+			"access":       colAccess,
+		}, params.fields)
 	}
+	response["collections"] = items
 
-	c.JSON(http.StatusOK, gin.H{"collections": response})
+	c.JSON(http.StatusOK, response)
 }
 
 // GetCollectionAPI – получение коллекции
 // @Summary Получение коллекции по ID
-// @Description Возвращает коллекцию и список её документов.
+// @Description Возвращает коллекцию и страницу её документов без содержимого, включая документы всех участников. Параметры страницы, сортировки, полей и фильтров документов
+// @Description такие же, как у GET /api/documents.
 // @Tags Коллекции
 // @Security BearerAuth
 // @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
 // @Param id path int true "ID коллекции"
-// @Success 200 {object} map[string]interface{} "{"id":int,"name":string,"documents":[]map[string]interface{}}"
+// @Param limit query int false "Размер страницы документов (1-200, по умолчанию 50)"
+// @Param cursor query string false "Курсор следующей страницы документов"
+// @Param sort query string false "Сортировка документов: name, created_at (по умолчанию) или size; -поле - по убыванию"
+// @Param fields query string false "Поля документов через запятую"
+// @Success 200 {object} map[string]interface{} "{"id":int,"name":string,"created_at":string,"owner_id":int,"access":string,"documents":[]DocumentResponse,"total":int,"next_cursor":string}"
+// @Failure 400 {object} map[string]string "Invalid limit, cursor, sort, fields or filter"
 // @Failure 404 {object} map[string]string "Collection not found"
 // @Router /api/collections/{id} [get]
 func GetCollectionAPI(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
 	id, _ := strconv.Atoi(c.Param("id"))
 
-	var collection models.Collection
-	if err := db.DB.Preload("Documents").
-		Where("id = ? AND user_id = ?", id, userID).
-		First(&collection).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+	collection, access, ok := findCollection(c, id, models.AccessViewer)
+	if !ok {
 		return
 	}
 
-	documents := make([]gin.H, len(collection.Documents))
-	for i, doc := range collection.Documents {
-		documents[i] = gin.H{
-			"id":   doc.ID,
-			"name": doc.Filename,
-		}
+	page, ok := documentPage(c, db.DB.Model(&models.Document{}).
+		Where("documents.id IN (SELECT document_id FROM collection_documents WHERE collection_id = ?)", collection.ID))
+	if !ok {
+		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"id":        collection.ID,
-		"name":      collection.Name,
-		"documents": documents,
-	})
+	page["id"] = collection.ID
+	page["name"] = collection.Name
+	page["created_at"] = collection.CreatedAt
+	page["owner_id"] = collection.UserID
+	page["access"] = access
+	c.JSON(http.StatusOK, page)
 }
 
 // CollectionStatisticsAPI – статистика коллекции
 // @Summary TF‑IDF статистика коллекции
-// @Description Рассчитывает TF‑IDF внутри всех документов коллекции.
+// @Description Рассчитывает TF‑IDF внутри всех документов коллекции, в том числе принадлежащих разным участникам.
 // @Tags Коллекции
 // @Security BearerAuth
 // @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
 // @Param id path int true "ID коллекции"
 // @Success 200 {object} map[string]interface{} "{"collection_id":int,"statistics":map[string]object}"
 // @Failure 404 {object} map[string]string "Collection not found"
+// @Failure 500 {object} map[string]string "Database error"
 // @Router /api/collections/{id}/statistics [get]
 func CollectionStatisticsAPI(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
 	id, _ := strconv.Atoi(c.Param("id"))
 
-	var col models.Collection
-	if err := db.DB.Preload("Documents").
-		Where("id = ? AND user_id = ?", id, userID).
-		First(&col).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+	col, _, ok := findCollection(c, id, models.AccessViewer)
+	if !ok {
+		return
+	}
+	result, err := collectionStatistics(col.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"collection_id": col.ID,
+		"statistics":    result,
+	})
+}
+
+// collectionStatistics - TF по объединённому тексту документов коллекции и IDF слов, 50 слов с наименьшим TF
+func collectionStatistics(collectionID uint) (map[string]gin.H, error) {
+	var col models.Collection
+	if err := db.DB.Preload("Documents.Blob").First(&col, collectionID).Error; err != nil {
+		return nil, err
+	}
+
 	var combinedText strings.Builder
 	for _, doc := range col.Documents {
-		combinedText.WriteString(doc.ProcessedContent)
+		combinedText.WriteString(doc.Blob.ProcessedContent)
 		combinedText.WriteString(" ")
 	}
 
 	tf := calculation.CountTf([]string{combinedText.String()})
 
 	var idfRecs []models.CollectionIDF
-	db.DB.Where("collection_id = ?", id).Find(&idfRecs)
+	if err := db.DB.Where("collection_id = ?", collectionID).Find(&idfRecs).Error; err != nil {
+		return nil, err
+	}
 	idfMap := make(map[string]float64, len(idfRecs))
 	for _, rec := range idfRecs {
 		idfMap[rec.Word] = rec.IDFValue
@@ -210,52 +406,40 @@ func CollectionStatisticsAPI(c *gin.Context) {
 		result[item.Word] = gin.H{"tf": item.TF, "idf": item.IDF}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"collection_id": col.ID,
-		"statistics":    result,
-	})
+	return result, nil
 }
 
 // AddDocumentToCollectionAPI – добавление документа
 // @Summary Добавление документа в коллекцию
-// @Description Присоединяет документ к коллекции и обновляет IDF.
+// @Description Присоединяет документ пользователя к коллекции и обновляет IDF. Требуется доступ owner или editor.
 // @Tags Коллекции
 // @Security BearerAuth
 // @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
 // @Param collection_id path int true "ID коллекции"
 // @Param document_id path int true "ID документа"
 // @Success 200 {object} map[string]string "{"message":"Document added to collection"}"
+// @Failure 403 {object} map[string]string "Collection access is not enough"
 // @Failure 404 {object} map[string]string "Collection or Document not found"
 // @Failure 500 {object} map[string]string "Failed to add document or update IDF"
 // @Router /api/collection/{collection_id}/{document_id} [post]
 func AddDocumentToCollectionAPI(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
 	collectionID, _ := strconv.Atoi(c.Param("collection_id"))
 	documentID, _ := strconv.Atoi(c.Param("document_id"))
 
-	var col models.Collection
-	if err := db.DB.Where("id = ? AND user_id = ?", collectionID, userID).
-		First(&col).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+	col, _, ok := findCollection(c, collectionID, models.AccessEditor)
+	if !ok {
 		return
 	}
 
+	// Добавлять можно только документы активного пространства, в том числе в чужую коллекцию с доступом editor
 	var doc models.Document
-	if err := db.DB.Where("id = ? AND user_id = ?", documentID, userID).
+	if err := requestScope(c).where(db.DB, "documents").Where("documents.id = ?", documentID).
 		First(&doc).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
 		return
 	}
 
-	var count int64
-	db.DB.Model(&models.Collection{}).
-		Where("id = ? AND user_id = ?", collectionID, userID).
-		Count(&count)
-	if count == 0 {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Collection doesn't belong to user"})
-		return
-	}
-
 	if err := db.DB.Model(&col).
 		Association("Documents").
 		Append(&doc); err != nil {
@@ -263,7 +447,7 @@ func AddDocumentToCollectionAPI(c *gin.Context) {
 		return
 	}
 
-	if err := recalcCollectionIDF(uint(collectionID), userID); err != nil {
+	if err := recalcCollectionIDF(c.Request.Context(), uint(collectionID)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update IDF"})
 		return
 	}
@@ -272,33 +456,101 @@ func AddDocumentToCollectionAPI(c *gin.Context) {
 
 }
 
+// AddDocumentsByTagsAPI – добавление документов по тегам
+// @Summary Добавление документов в коллекцию по тегам
+// @Description Добавляет в коллекцию все документы пользователя, у которых есть все указанные теги, и один раз пересчитывает IDF.
+// @Description Документы, которые уже состоят в коллекции, пропускаются. Требуется доступ owner или editor.
+// @Tags Коллекции
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path int true "ID коллекции"
+// @Param body body controllers.AddDocumentsByTagsRequest true "Теги"
+// @Success 200 {object} map[string]interface{} "{"added":int,"document_ids":[]int}"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Collection access is not enough"
+// @Failure 404 {object} map[string]string "Collection not found"
+// @Failure 500 {object} map[string]string "Failed to add documents or update IDF"
+// @Router /api/collections/{id}/documents [post]
+func AddDocumentsByTagsAPI(c *gin.Context) {
+	scope := requestScope(c)
+	collectionID, _ := strconv.Atoi(c.Param("id"))
+
+	var req AddDocumentsByTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	tags, err := metadata.NormalizeTags(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	col, _, ok := findCollection(c, collectionID, models.AccessEditor)
+	if !ok {
+		return
+	}
+
+	var docs []*models.Document
+	query := scope.where(db.DB, "documents").
+		Where("id NOT IN (SELECT document_id FROM collection_documents WHERE collection_id = ?)", col.ID)
+	if err := withTags(query, tags).Order("id").Find(&docs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	documentIDs := make([]uint, len(docs))
+	for i, doc := range docs {
+		documentIDs[i] = doc.ID
+	}
+	if len(docs) > 0 {
+		if err := db.DB.Model(&col).Association("Documents").Append(docs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add documents"})
+			return
+		}
+		if err := recalcCollectionIDF(c.Request.Context(), col.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update IDF"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"added": len(docs), "document_ids": documentIDs})
+}
+
 // RemoveDocumentFromCollectionAPI – удаление документа
 // @Summary Удаление документа из коллекции
-// @Description Убирает документ из коллекции и обновляет IDF.
+// @Description Убирает документ из коллекции и обновляет IDF. Владелец и редактор рабочего пространства убирают любые документы,
+// @Description редактор по совместному доступу - только документы своего пространства.
 // @Tags Коллекции
 // @Security BearerAuth
 // @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
 // @Param collection_id path int true "ID коллекции"
 // @Param document_id path int true "ID документа"
 // @Success 200 {object} map[string]string "{"message":"Document removed from collection"}"
+// @Failure 403 {object} map[string]string "Collection access is not enough"
 // @Failure 404 {object} map[string]string "Collection or Document not found"
 // @Failure 500 {object} map[string]string "Failed to remove document or update IDF"
 // @Router /api/collection/{collection_id}/{document_id} [delete]
 func RemoveDocumentFromCollectionAPI(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
 	collectionID, _ := strconv.Atoi(c.Param("collection_id"))
 	documentID, _ := strconv.Atoi(c.Param("document_id"))
 
-	var col models.Collection
-	if err := db.DB.Where("id = ? AND user_id = ?", collectionID, userID).
-		First(&col).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+	col, access, ok := findCollection(c, collectionID, models.AccessEditor)
+	if !ok {
 		return
 	}
 
+	// Владелец (и редактор рабочего пространства) убирает любые документы коллекции,
+	// редактор по совместному доступу - только документы своего активного пространства
+	query := db.DB.Where("documents.id = ? AND documents.id IN (SELECT document_id FROM collection_documents WHERE collection_id = ?)", documentID, col.ID)
+	if !managesCollection(col, access, requestScope(c)) {
+		query = requestScope(c).where(query, "documents")
+	}
 	var doc models.Document
-	if err := db.DB.Where("id = ? AND user_id = ?", documentID, userID).
-		First(&doc).Error; err != nil {
+	if err := query.First(&doc).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
 		return
 	}
@@ -312,7 +564,7 @@ func RemoveDocumentFromCollectionAPI(c *gin.Context) {
 	}
 
 	// Пересчёт IDF
-	if err := recalcCollectionIDF(uint(collectionID), userID); err != nil {
+	if err := recalcCollectionIDF(c.Request.Context(), uint(collectionID)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update IDF"})
 		return
 	}
@@ -322,24 +574,28 @@ func RemoveDocumentFromCollectionAPI(c *gin.Context) {
 
 // DeleteCollectionAPI – удаление коллекции
 // @Summary Удаление коллекции
-// @Description Удаляет коллекцию и все связанные IDF.
+// @Description Перемещает коллекцию в корзину и удаляет её IDF. Документы коллекции не удаляются.
+// @Description Доступно владельцу, коллекцию рабочего пространства удаляет и его редактор.
 // @Tags Коллекции
 // @Security BearerAuth
 // @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
 // @Param id path int true "ID коллекции"
-// @Success 200 {object} map[string]string "{"message":"Collection deleted"}"
+// @Success 200 {object} map[string]string "{"message":"Collection moved to trash"}"
+// @Failure 403 {object} map[string]string "Collection access is not enough"
 // @Failure 404 {object} map[string]string "Collection not found"
 // @Failure 500 {object} map[string]string "Failed to delete collection or related data"
 // @Router /api/collections/{id} [delete]
 func DeleteCollectionAPI(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
 	collectionID, _ := strconv.Atoi(c.Param("id"))
 
-	// Проверка владельца коллекции
-	var collection models.Collection
-	if err := db.DB.Where("id = ? AND user_id = ?", collectionID, userID).
-		First(&collection).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+	// Проверка владельца коллекции; коллекцию рабочего пространства удаляет и его редактор
+	collection, access, ok := findCollection(c, collectionID, models.AccessEditor)
+	if !ok {
+		return
+	}
+	if !managesCollection(collection, access, requestScope(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Collection access " + access + " is not enough, owner required"})
 		return
 	}
 
@@ -350,11 +606,11 @@ func DeleteCollectionAPI(c *gin.Context) {
 		return
 	}
 
-	// Удаление коллекции
+	// Перемещение в корзину, состав коллекции сохраняется для восстановления
 	if err := db.DB.Delete(&collection).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete in Database"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "Collection moved to trash"})
 }
@@ -1,30 +1,71 @@
 package controllers
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
+	"path"
 	"sort"
-	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"LestaStartTest/internal/archive"
 	"LestaStartTest/internal/calculation"
 	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/extract"
+	"LestaStartTest/internal/jobs"
 	"LestaStartTest/internal/models"
 	"LestaStartTest/internal/monitoring"
+	"LestaStartTest/internal/quota"
+	"LestaStartTest/internal/storage"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// Политики разрешения конфликтов имён при загрузке
+const (
+	conflictRename  = "rename"
+	conflictReplace = "replace"
+	conflictReject  = "reject"
+)
+
+// Тип фоновой задачи обработки загруженных файлов
+const jobUpload = "upload"
+
+// uploadJob - параметры задачи обработки загруженных файлов
+type uploadJob struct {
+	Files             []uploadFile `json:"files"`
+	Encoding          string       `json:"encoding"`
+	OnConflict        string       `json:"on_conflict"`
+	ArchiveCollection bool         `json:"archive_collection"`
+	Atomic            bool         `json:"atomic"`                 // при любой ошибке не сохраняется ни один файл
+	WorkspaceID       *uint        `json:"workspace_id,omitempty"` // рабочее пространство, в которое загружаются документы
+}
+
+// uploadFile - загруженный файл во временном хранилище
+type uploadFile struct {
+	Name  string   `json:"name"`
+	Key   string   `json:"key,omitempty"`
+	Parts []string `json:"parts,omitempty"` // части файла из сессии возобновляемой загрузки
+}
+
 // Структуры для API-ответов
 
 type UploadResponse struct {
-	ID       uint   `json:"id"`
-	Filename string `json:"filename"`
+	ID        uint   `json:"id"`
+	Filename  string `json:"filename"`
+	Encoding  string `json:"encoding"`
+	MimeType  string `json:"mime_type"`
+	Duplicate bool   `json:"duplicate"` // такое же содержимое уже было загружено, файл повторно не сохранялся
 }
 
 type WordStat struct {
@@ -33,140 +74,511 @@ type WordStat struct {
 	Idf  float64 `json:"idf"`
 }
 
+type UploadCollection struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// Результаты обработки отдельного файла
+const (
+	fileStored  = "stored"
+	fileSkipped = "skipped"
+	fileFailed  = "failed"
+)
+
+// FileResult - результат обработки одного файла запроса или одного файла архива
+type FileResult struct {
+	Name       string `json:"name"` // имя файла в запросе, для файлов архива - "архив/путь"
+	Status     string `json:"status" enums:"stored,skipped,failed"`
+	Reason     string `json:"reason,omitempty"`
+	DocumentID uint   `json:"document_id,omitempty"`
+}
+
 type UploadResult struct {
-	User      models.User      `json:"user"`
-	Documents []UploadResponse `json:"documents"`
-	TopWords  []WordStat       `json:"top_words"`
+	User        models.User        `json:"user"`
+	Files       []FileResult       `json:"files"`
+	Documents   []UploadResponse   `json:"documents"`
+	Collections []UploadCollection `json:"collections,omitempty"`
+	TopWords    []WordStat         `json:"top_words"`
 }
 
 // UploadAPI – загрузка документов
 // @Summary Загрузка файлов
-// @Description Загружает один или несколько файлов, обрабатывает и сохраняет их.
+// @Description Загружает один или несколько файлов, извлекает из них текст и сохраняет.
+// @Description Поддерживаются текстовые файлы, Markdown, HTML, RTF, DOCX, ODT и PDF с текстовым слоем.
+// @Description Архивы zip, tar и tar.gz распаковываются, каждый файл архива становится отдельным документом.
+// @Description Файлы хранятся по SHA-256: повторно загруженное содержимое не сохраняется заново и помечается в ответе как duplicate.
+// @Description Количество и размер файлов, а также суммарный объём и число документов пользователя ограничены квотами (см. GET /api/user/usage).
+// @Description Файлы обрабатываются в фоновой задаче: ответ содержит её ID, прогресс и итоговый UploadResult доступны в GET /api/jobs/{id}.
+// @Description В UploadResult.files для каждого файла указан итог: stored, skipped (имя занято при on_conflict=reject или содержимое не изменилось при replace) или failed с причиной.
 // @Tags Документы
 // @Security BearerAuth
 // @Accept multipart/form-data
 // @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
 // @Param files formData []file true "Файлы для загрузки" collectionFormat(multi)
-// @Success 200 {object} map[string]interface{} "{"message":string,"data":UploadResult}"
-// @Failure 400 {object} map[string]string "Error getting files or no files uploaded"
-// @Failure 500 {object} map[string]interface{} "{"errors":[]string}"
+// @Param encoding formData string false "Кодировка файлов (UTF-8, UTF-16LE, UTF-16BE, windows-1251, KOI8-R, IBM866), по умолчанию определяется автоматически. Для уже загруженного ранее содержимого используется сохранённый текст"
+// @Param archive_collection formData bool false "Добавить файлы из архива в коллекцию с именем архива (создаётся при отсутствии)"
+// @Param atomic formData bool false "Всё или ничего: при ошибке любого файла или конфликте имён не сохраняется ни один файл. По умолчанию сохраняются все файлы, которые удалось обработать"
+// @Param on_conflict formData string false "Действие при совпадении имени с существующим документом: rename (по умолчанию), replace (новая версия существующего документа) или reject" Enums(rename, replace, reject)
+// @Success 202 {object} JobResponse "Задача создана, заголовок Location указывает на её статус"
+// @Failure 400 {object} map[string]string "Error getting files, no files uploaded, unknown encoding or on_conflict"
+// @Failure 413 {object} map[string]string "Превышен лимит размера файла, количества файлов или квота пользователя"
+// @Failure 500 {object} map[string]string "Failed to store files or create job"
 // @Router /api/documents/upload [post]
 func UploadAPI(c *gin.Context) {
-	start := time.Now() // Фиксируем время начала обработки
 	userID := c.MustGet("userID").(uint)
+	ctx := c.Request.Context()
 
-	// Получение информации о пользователе
-	var user models.User
-	if err := db.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User not found"})
-		return
+	// Размер тела запроса ограничивается до разбора формы, чтобы большие запросы не записывались во временные файлы
+	limits := quota.LimitsFromEnv()
+	if n := limits.RequestBodyLimit(); n > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, n)
 	}
 
 	// Получение файлов
 	form, err := c.MultipartForm()
 	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body exceeds " + quota.FormatBytes(tooLarge.Limit)})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Error getting files"})
+		return
 	}
 
 	// Проверка на наличие загруженных файлов
 	files := form.File["files"]
 	if len(files) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No files uploaded"})
+		return
+	}
+
+	// Количество и размер файлов проверяются до чтения их в память
+	if err := limits.CheckFiles(len(files)); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	for _, file := range files {
+		if err := limits.CheckFile(file.Filename, file.Size); err != nil {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Принудительная кодировка и политика разрешения конфликтов имён
+	encoding, onConflict, err := normalizeUploadOptions(c.PostForm("encoding"), c.PostForm("on_conflict"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
+	// Предварительная проверка квоты по размеру загруженных файлов. Точная проверка (после распаковки архивов
+	// и с учётом заменяемых документов) выполняется при обработке. Учитываются и незавершённые сессии загрузки.
+	usage, err := reservedUsage(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error checking quota"})
+		return
+	}
+	var uploadSize int64
+	for _, file := range files {
+		uploadSize += file.Size
+	}
+	if err := limits.CheckUsage(usage, quota.Usage{Bytes: uploadSize}); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Файлы сохраняются во временное хранилище, чтобы задача пережила перезапуск приложения
+	params := uploadJob{
+		Files:             make([]uploadFile, len(files)),
+		Encoding:          encoding,
+		OnConflict:        onConflict,
+		ArchiveCollection: c.PostForm("archive_collection") == "true",
+		Atomic:            c.PostForm("atomic") == "true",
+		WorkspaceID:       requestScope(c).WorkspaceID,
+	}
+	keys := make([]string, 0, len(files))
+	for i, file := range files {
+		key := jobs.NewFileKey()
+		if err := stageFile(ctx, key, file); err != nil {
+			db.RemoveBlobFiles(ctx, keys)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file " + file.Filename})
+			return
+		}
+		keys = append(keys, key)
+		params.Files[i] = uploadFile{Name: file.Filename, Key: key}
+	}
+
+	job := models.Job{UserID: userID, Type: jobUpload, Total: len(files), Files: keys}
+	if err := jobs.Create(&job, params); err != nil {
+		db.RemoveBlobFiles(ctx, keys)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job"})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/jobs/%d", job.ID))
+	c.JSON(http.StatusAccepted, jobResponse(job))
+}
+
+// processUpload - обработка загруженных файлов в фоновой задаче: извлечение текста, сохранение документов
+// и расчёт статистики. Результат - UploadResult.
+func processUpload(ctx context.Context, run *jobs.Run) (any, error) {
+	start := time.Now() // Фиксируем время начала обработки
+	userID := run.Job.UserID
+
+	var params uploadJob
+	if err := run.Decode(&params); err != nil {
+		return nil, err
+	}
+	files, encoding := params.Files, params.Encoding
+	scope := ownerScope{UserID: userID, WorkspaceID: params.WorkspaceID}
+
+	// Получение информации о пользователе; хэш пароля в результат задачи не попадает
+	var user models.User
+	if err := db.DB.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	user.Password = ""
+
+	// Документы уже сохранены прерванным запуском - остаётся обновить коллекции и собрать результат
+	var commit uploadCommit
+	if ok, err := run.Restore(&commit); err != nil {
+		return nil, err
+	} else if ok {
+		contents, err := blobContents(commit.Hashes)
+		if err != nil {
+			return nil, fmt.Errorf("database error loading documents: %w", err)
+		}
+		return finishUpload(ctx, scope, user, params, commit, contents, start)
+	}
+
+	// Пока задача ждала в очереди, пользователя могли исключить из рабочего пространства или лишить права изменений
+	if scope.WorkspaceID != nil {
+		var member models.WorkspaceMember
+		if err := db.DB.Where("workspace_id = ? AND user_id = ?", *scope.WorkspaceID, userID).First(&member).Error; err != nil ||
+			member.Role == models.AccessViewer {
+			return nil, errors.New("no longer allowed to upload to the workspace")
+		}
+	}
+
+	// Ограничения на распаковку архивов и размер файлов
+	limits := quota.LimitsFromEnv()
+	archiveLimits := archive.LimitsFromEnv()
+	run.SetTotal(len(files))
+
 	var (
-		allContents       []string
 		wg                sync.WaitGroup
 		mu                sync.Mutex
-		errCh             = make(chan error, len(files))
+		failed            []FileResult // файлы, которые не удалось прочитать или распознать
 		uploadedDocuments = make([]models.Document, 0, len(files))
+		documentSources   = make([]string, 0, len(files)) // имя файла в запросе, для файлов архива - "архив/путь"
+		documentArchives  = make([]string, 0, len(files)) // архив, из которого получен документ
+		blobData          = make(map[string][]byte)       // исходные данные по SHA-256
 	)
 
-	// Создаем директорию пользователя
-	userUploadDir := filepath.Join("uploads", strconv.Itoa(int(userID)))
-	if err := os.MkdirAll(userUploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user directory"})
-		return
-	}
+	// processFile - чтение файла и извлечение текста. Ошибка отдельного файла архива не мешает остальным.
+	processFile := func(file uploadFile) error {
+		fail := func(name string, err error) error {
+			mu.Lock()
+			failed = append(failed, FileResult{Name: name, Status: fileFailed, Reason: err.Error()})
+			mu.Unlock()
+			return fmt.Errorf("%s: %w", name, err)
+		}
 
-	// Обработка каждого файла в отдельной горутине
-	for _, f := range files {
-		wg.Add(1)
-		go func(file *multipart.FileHeader) {
-			defer wg.Done()
+		// Чтение файла из временного хранилища
+		content, err := readUploadFile(ctx, file)
+		if err != nil {
+			return fail(file.Name, err)
+		}
 
-			// Открытие файла
-			src, err := file.Open()
-			if err != nil {
-				errCh <- fmt.Errorf("error opening file %s: %w", file.Filename, err)
-				return
+		// Архив распаковывается в отдельные документы с относительными путями в имени,
+		// у обычного файла путь клиента отбрасывается
+		entries := []archive.Entry{{Name: path.Base(strings.ReplaceAll(file.Name, "\\", "/")), Data: content}}
+		archiveName := ""
+		if isArchive(extract.DetectMIME(file.Name, content)) {
+			if entries, err = archive.Expand(file.Name, content, archiveLimits); err != nil {
+				return fail(file.Name, err)
 			}
-			defer src.Close()
+			archiveName = file.Name
+		}
 
-			// Создание пути для сохранения
-			filePath := filepath.Join(userUploadDir, file.Filename)
-			dst, err := os.Create(filePath)
-			if err != nil {
-				errCh <- fmt.Errorf("error creating file %s: %w", filePath, err)
-				return
+		var entryErrors []error
+		for _, entry := range entries {
+			source := file.Name
+			if archiveName != "" {
+				source = archiveName + "/" + entry.Name
 			}
-			defer dst.Close()
 
-			// Копирование файла
-			if _, err := io.Copy(dst, src); err != nil {
-				errCh <- fmt.Errorf("error saving file %s: %w", filePath, err)
-				return
+			// Пользователю показывается очищенное имя, файл хранится под SHA-256 содержимого
+			name := storage.SanitizeFilename(entry.Name)
+			if archiveName != "" {
+				if err := limits.CheckFile(name, int64(len(entry.Data))); err != nil {
+					entryErrors = append(entryErrors, fail(source, err))
+					continue
+				}
 			}
-
-			// Чтение и обработка содержимого
-			content, err := os.ReadFile(filePath)
+			blob, err := newBlob(name, entry.Data, encoding)
 			if err != nil {
-				errCh <- fmt.Errorf("error reading file %s: %w", filePath, err)
-				return
+				entryErrors = append(entryErrors, fail(source, err))
+				continue
 			}
-			cleanContent := calculation.PunctuationRemoveAndLower(string(content))
 
 			mu.Lock()
-			allContents = append(allContents, cleanContent)
 			uploadedDocuments = append(uploadedDocuments, models.Document{
-				UserID:           userID,
-				Filename:         file.Filename,
-				OriginalPath:     filePath,
-				Content:          string(content),
-				ProcessedContent: cleanContent,
+				UserID:      userID,
+				WorkspaceID: scope.WorkspaceID,
+				Filename:    name,
+				Blob:        blob,
 			})
+			documentSources = append(documentSources, source)
+			documentArchives = append(documentArchives, archiveName)
+			blobData[blob.Hash] = entry.Data
 			mu.Unlock()
+		}
+		return errors.Join(entryErrors...)
+	}
+
+	// Обработка каждого файла в отдельной горутине; ошибки файлов сохраняются в задаче.
+	// Паника при разборе файла (recover в jobs работает только в горутине задачи) считается ошибкой этого файла.
+	for _, f := range files {
+		wg.Add(1)
+		go func(file uploadFile) {
+			defer wg.Done()
+			defer func() {
+				if p := recover(); p != nil {
+					log.Printf("Panic while processing %q: %v", file.Name, p)
+					mu.Lock()
+					failed = append(failed, FileResult{Name: file.Name, Status: fileFailed, Reason: "internal error while processing file"})
+					mu.Unlock()
+					run.Step(file.Name, fmt.Errorf("%s: panic: %v", file.Name, p))
+				}
+			}()
+			run.Step(file.Name, processFile(file))
 		}(f)
 	}
 
 	// Ожидание завершения обработки файлов
 	wg.Wait()
-	close(errCh)
 
-	// Проверка ошибок
-	var errors []string
-	for err := range errCh {
-		errors = append(errors, err.Error())
+	// В атомарном режиме любая ошибка отменяет всю загрузку
+	if params.Atomic && len(failed) > 0 {
+		return nil, fmt.Errorf("%d files failed, nothing was stored (atomic)", len(failed))
 	}
-	if len(errors) > 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{"errors": errors})
-		return
+	if len(uploadedDocuments) == 0 {
+		return nil, fmt.Errorf("all %d files failed", len(failed))
+	}
+
+	// Разрешение конфликтов имён в пространстве документов пользователя или рабочем пространстве
+	replaced, conflicts, err := resolveConflicts(scope, params.OnConflict, uploadedDocuments)
+	if err != nil {
+		return nil, fmt.Errorf("database error checking filenames: %w", err)
+	}
+	if params.Atomic && len(conflicts) > 0 {
+		names := make([]string, len(conflicts))
+		for i, idx := range conflicts {
+			names[i] = uploadedDocuments[idx].Filename
+		}
+		return nil, fmt.Errorf("documents already exist: %s", strings.Join(names, ", "))
+	}
+
+	// Пропускаются документы, отклонённые политикой reject, и замены без изменения содержимого
+	skipped := make(map[int]FileResult)
+	for _, i := range conflicts {
+		skipped[i] = FileResult{Name: documentSources[i], Status: fileSkipped, Reason: "document " + uploadedDocuments[i].Filename + " already exists"}
+	}
+	for i, old := range replaced {
+		if old.BlobHash == uploadedDocuments[i].Blob.Hash {
+			skipped[i] = FileResult{Name: documentSources[i], Status: fileSkipped, Reason: "content unchanged", DocumentID: old.ID}
+			delete(replaced, i)
+		}
+	}
+
+	// Проверка квоты: каждый файл занимает место как новая версия, документом больше - только если он не заменяет существующий
+	var added quota.Usage
+	for i, doc := range uploadedDocuments {
+		if _, ok := skipped[i]; ok {
+			continue
+		}
+		added.Bytes += doc.Blob.Size
+		if _, ok := replaced[i]; !ok {
+			added.Documents++
+		}
+	}
+	usage, err := userUsage(userID)
+	if err != nil {
+		return nil, fmt.Errorf("database error checking quota: %w", err)
+	}
+	if err := limits.CheckUsage(usage, added); err != nil {
+		return nil, err
 	}
 
-	// Сохранение документов в БД
+	// Сохранение документов в БД. Содержимое, которое уже есть в хранилище, повторно не записывается.
+	// При replace загруженный файл становится новой версией существующего документа,
+	// поэтому его ID и членство в коллекциях сохраняются.
+	// Без atomic каждый документ сохраняется в своей точке сохранения, и ошибка одного не отменяет остальные.
+	var (
+		stored     = make([]bool, len(uploadedDocuments))
+		duplicates = make([]bool, len(uploadedDocuments))
+		newKeys    []string // файлы, записанные этим запросом; удаляются при откате
+		failedKeys []string // файлы документов, сохранение которых откатилось
+	)
 	tx := db.DB.Begin()
-	for _, doc := range uploadedDocuments {
-		if err := tx.Create(&doc).Error; err != nil {
+	for i := range uploadedDocuments {
+		if _, ok := skipped[i]; ok {
+			continue
+		}
+		doc := &uploadedDocuments[i]
+		if old, ok := replaced[i]; ok {
+			doc.ID = old.ID
+		}
+		if !params.Atomic {
+			tx.SavePoint("document")
+		}
+		known, err := saveVersion(ctx, tx, doc, blobData[doc.Blob.Hash])
+		if !known {
+			newKeys = append(newKeys, doc.Blob.StorageKey)
+		}
+		if err == nil {
+			stored[i], duplicates[i] = true, known
+			continue
+		}
+
+		reason := "database error saving document"
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			reason = "document " + doc.Filename + " already exists"
+		}
+		if params.Atomic {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error saving documents"})
-			return
+			db.RemoveBlobFiles(ctx, newKeys)
+			return nil, fmt.Errorf("%s: %s, nothing was stored (atomic)", documentSources[i], reason)
+		}
+		tx.RollbackTo("document")
+		if !known {
+			failedKeys = append(failedKeys, doc.Blob.StorageKey)
+		}
+		doc.ID = 0
+		failed = append(failed, FileResult{Name: documentSources[i], Status: fileFailed, Reason: reason})
+		run.AddError(fmt.Errorf("%s: %s", documentSources[i], reason))
+	}
+	// Результаты по файлам записываются в задачу в той же транзакции, что и документы:
+	// если задача прервётся после фиксации, повторный запуск не сохранит их второй раз
+	var contents []string
+	fileResults := failed
+	for i, doc := range uploadedDocuments {
+		if result, ok := skipped[i]; ok {
+			fileResults = append(fileResults, result)
+			continue
+		}
+		if !stored[i] {
+			continue
+		}
+		commit.Documents = append(commit.Documents, UploadResponse{
+			ID:        doc.ID,
+			Filename:  doc.Filename,
+			Encoding:  doc.Blob.Encoding,
+			MimeType:  doc.Blob.MimeType,
+			Duplicate: duplicates[i],
+		})
+		commit.Hashes = append(commit.Hashes, doc.Blob.Hash)
+		contents = append(contents, doc.Blob.ProcessedContent)
+		fileResults = append(fileResults, FileResult{Name: documentSources[i], Status: fileStored, DocumentID: doc.ID})
+		if old, ok := replaced[i]; ok {
+			commit.ReplacedIDs = append(commit.ReplacedIDs, old.ID)
+		}
+		if name := documentArchives[i]; name != "" && params.ArchiveCollection {
+			if commit.Archives == nil {
+				commit.Archives = make(map[string][]uint)
+			}
+			commit.Archives[name] = append(commit.Archives[name], doc.ID)
 		}
 	}
-	tx.Commit()
+	sort.Slice(fileResults, func(i, j int) bool {
+		return fileResults[i].Name < fileResults[j].Name
+	})
+	commit.Files = fileResults
+
+	if err := run.Checkpoint(tx, commit); err != nil {
+		tx.Rollback()
+		db.RemoveBlobFiles(ctx, newKeys)
+		return nil, fmt.Errorf("database error saving documents: %w", err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		db.RemoveBlobFiles(ctx, newKeys)
+		return nil, fmt.Errorf("database error saving documents: %w", err)
+	}
+	if err := db.RemoveOrphanFiles(ctx, failedKeys); err != nil {
+		log.Printf("Failed to remove orphan files: %v", err)
+	}
+
+	return finishUpload(ctx, scope, user, params, commit, contents, start)
+}
+
+// uploadCommit - итог сохранения документов загрузки. Записывается в задачу вместе с документами,
+// с него продолжается повторный запуск прерванной задачи.
+type uploadCommit struct {
+	Files       []FileResult      `json:"files"`
+	Documents   []UploadResponse  `json:"documents"`
+	Hashes      []string          `json:"hashes"`                 // содержимое сохранённых документов в порядке Documents
+	ReplacedIDs []uint            `json:"replaced_ids,omitempty"` // документы, получившие новую версию
+	Archives    map[string][]uint `json:"archives,omitempty"`     // документы из архивов для archive_collection
+}
+
+// blobContents - обработанные тексты содержимого по списку хешей (с повторами)
+func blobContents(hashes []string) ([]string, error) {
+	var blobs []models.Blob
+	if err := db.DB.Select("hash", "processed_content").Where("hash IN ?", hashes).Find(&blobs).Error; err != nil {
+		return nil, err
+	}
+	byHash := make(map[string]string, len(blobs))
+	for _, blob := range blobs {
+		byHash[blob.Hash] = blob.ProcessedContent
+	}
+	contents := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		if content, ok := byHash[hash]; ok {
+			contents = append(contents, content)
+		}
+	}
+	return contents, nil
+}
+
+// finishUpload - шаги загрузки после сохранения документов: пересчёт IDF коллекций заменённых документов,
+// коллекции архивов и статистика. Шаги можно повторить: IDF пересчитывается заново, коллекция архива
+// находится по имени, а уже добавленные в неё документы не дублируются.
+func finishUpload(ctx context.Context, scope ownerScope, user models.User, params uploadJob, commit uploadCommit,
+	allContents []string, start time.Time) (any, error) {
+	// Пересчёт IDF коллекций, в которых состоят заменённые документы
+	if len(commit.ReplacedIDs) > 0 {
+		if err := recalcDocumentCollections(ctx, commit.ReplacedIDs); err != nil {
+			return nil, fmt.Errorf("failed to update IDF: %w", err)
+		}
+	}
+
+	// Документы из архивов добавляются в коллекции с именем архива
+	var collections []UploadCollection
+	for name, ids := range commit.Archives {
+		var docs []*models.Document
+		if err := db.DB.Where("id IN ?", ids).Find(&docs).Error; err != nil {
+			return nil, fmt.Errorf("database error loading documents: %w", err)
+		}
+		if len(docs) == 0 {
+			continue
+		}
+		col, err := addToNamedCollection(ctx, scope, archive.BaseName(name), docs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add documents to collection %s: %w", col.Name, err)
+		}
+		collections = append(collections, UploadCollection{ID: col.ID, Name: col.Name})
+	}
 
 	// Расчет статистики
-	var tf map[string]float64
-	var idf map[string]float64
+	var (
+		wg  sync.WaitGroup
+		tf  map[string]float64
+		idf map[string]float64
+	)
 
 	wg.Add(2)
 	go func() {
@@ -200,28 +612,137 @@ func UploadAPI(c *gin.Context) {
 		wordStats = wordStats[:50]
 	}
 
-	// Формируем ответ с документами
-	documentsResponse := make([]UploadResponse, len(uploadedDocuments))
-	for i, doc := range uploadedDocuments {
-		documentsResponse[i] = UploadResponse{
-			ID:       doc.ID,
-			Filename: doc.Filename,
-		}
-	}
-
 	// Полный ответ
 	result := UploadResult{
-		User:      user,
-		Documents: documentsResponse,
-		TopWords:  wordStats,
+		User:        user,
+		Files:       commit.Files,
+		Documents:   commit.Documents,
+		Collections: collections,
+		TopWords:    wordStats,
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Files uploaded and processed successfully",
-		"data":    result,
-	})
-
 	// Обновление метрик
 	processingTime := time.Since(start).Nanoseconds()
-	monitoring.UpdateMetrics(len(files), processingTime)
+	monitoring.UpdateMetrics(len(params.Files), processingTime)
+
+	return result, nil
+}
+
+// stageFile - сохранение загруженного файла во временное хранилище до обработки задачей
+func stageFile(ctx context.Context, key string, file *multipart.FileHeader) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return storage.Blobs.Put(ctx, key, src, file.Size)
+}
+
+// readUploadFile - чтение загруженного файла из временного хранилища; файл из сессии собирается из частей
+func readUploadFile(ctx context.Context, file uploadFile) ([]byte, error) {
+	keys := file.Parts
+	if len(keys) == 0 {
+		keys = []string{file.Key}
+	}
+	var buf bytes.Buffer
+	for _, key := range keys {
+		src, err := storage.Blobs.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("error opening file: %w", err)
+		}
+		_, err = io.Copy(&buf, src)
+		src.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// isArchive - проверка, что файл нужно распаковать, а не извлекать из него текст
+func isArchive(mimeType string) bool {
+	return mimeType == extract.MIMEZip || mimeType == extract.MIMETar || mimeType == extract.MIMEGzip
+}
+
+// resolveConflicts - применение политики on_conflict к загружаемым документам.
+// Возвращает существующие документы, которые нужно заменить (индекс загружаемого -> документ),
+// и индексы документов, отклонённых политикой reject. Одноимённые файлы внутри одного запроса переименовываются.
+func resolveConflicts(scope ownerScope, policy string, docs []models.Document) (map[int]models.Document, []int, error) {
+	names := make([]string, len(docs))
+	for i := range docs {
+		names[i] = docs[i].Filename
+	}
+
+	var existing []models.Document
+	if err := scope.where(db.DB.Select("id", "filename", "blob_hash"), "documents").
+		Where("filename IN ?", names).
+		Find(&existing).Error; err != nil {
+		return nil, nil, err
+	}
+	byName := make(map[string]models.Document, len(existing))
+	for _, doc := range existing {
+		byName[doc.Filename] = doc
+	}
+
+	replaced := make(map[int]models.Document)
+	var conflicts []int
+	taken := make(map[string]bool, len(docs)) // имена, занятые документами этого запроса
+	for i := range docs {
+		name := docs[i].Filename
+		old, exists := byName[name]
+
+		switch {
+		case !exists && !taken[name]:
+		case policy == conflictReject:
+			conflicts = append(conflicts, i)
+			continue
+		case policy == conflictReplace && exists && !taken[name]:
+			replaced[i] = old
+		default:
+			free, err := freeFilename(scope, name, taken)
+			if err != nil {
+				return nil, nil, err
+			}
+			docs[i].Filename = free
+			name = free
+		}
+		taken[name] = true
+	}
+	return replaced, conflicts, nil
+}
+
+// freeFilename - первое свободное имя вида "report (N).txt"
+func freeFilename(scope ownerScope, name string, taken map[string]bool) (string, error) {
+	for n := 1; ; n++ {
+		candidate := storage.NumberedFilename(name, n)
+		if taken[candidate] {
+			continue
+		}
+		var count int64
+		if err := scope.where(db.DB.Model(&models.Document{}), "documents").
+			Where("filename = ?", candidate).
+			Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+	}
+}
+
+// newBlob - извлечение текста из файла и подготовка записи о его содержимом
+func newBlob(name string, data []byte, encoding string) (*models.Blob, error) {
+	extracted, mimeType, err := extract.Extract(name, data, encoding)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return &models.Blob{
+		Hash:             hex.EncodeToString(sum[:]),
+		Size:             int64(len(data)),
+		Content:          extracted.Text,
+		ProcessedContent: calculation.PunctuationRemoveAndLower(extracted.Text),
+		Encoding:         extracted.Encoding,
+		MimeType:         mimeType,
+	}, nil
 }
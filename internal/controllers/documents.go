@@ -1,80 +1,365 @@
 package controllers
 
 import (
-	"log"
+	"errors"
 	"net/http"
-	"os"
+	"slices"
 	"sort"
 	"strconv"
+	"time"
+	"unicode/utf8"
 
 	"LestaStartTest/internal/calculation"
 	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/metadata"
 	"LestaStartTest/internal/models"
+	"LestaStartTest/internal/pagination"
+	"LestaStartTest/internal/storage"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // DocumentResponse - структура для ответа API
 type DocumentResponse struct {
-	ID      uint   `json:"id"`
-	Name    string `json:"name"`
-	Content string `json:"content,omitempty"`
+	ID        uint              `json:"id"`
+	Name      string            `json:"name"`
+	Title     string            `json:"title,omitempty"`
+	Author    string            `json:"author,omitempty"`
+	SourceURL string            `json:"source_url,omitempty"`
+	Tags      []string          `json:"tags"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Content   string            `json:"content,omitempty"`
+	Encoding  string            `json:"encoding,omitempty"`
+	MimeType  string            `json:"mime_type,omitempty"`
+	Size      int64             `json:"size,omitempty"` // размер исходного файла текущей версии
+	Version   int               `json:"version,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// UpdateDocumentRequest - изменение имени и метаданных документа. Отсутствующие поля не меняются.
+type UpdateDocumentRequest struct {
+	Name      *string            `json:"name"`
+	Title     *string            `json:"title"`
+	Author    *string            `json:"author"`
+	SourceURL *string            `json:"source_url"`
+	Tags      *[]string          `json:"tags"`   // заменяет все теги документа
+	Fields    map[string]*string `json:"fields"` // null удаляет поле, остальные поля документа сохраняются
+}
+
+// Сортировки и поля ответа списков документов
+var (
+	documentSorts       = []string{"name", "created_at", "size"}
+	documentSortColumns = map[string]string{"name": "documents.filename", "created_at": "documents.created_at", "size": "blobs.size"}
+	documentFields      = []string{"id", "name", "title", "author", "source_url", "tags", "fields", "mime_type", "size", "version", "created_at"}
+)
+
+// DocumentPage - страница списка документов
+type DocumentPage struct {
+	Documents  []DocumentResponse `json:"documents"`
+	Total      int64              `json:"total"`                 // количество документов по фильтрам без учёта страниц
+	NextCursor string             `json:"next_cursor,omitempty"` // курсор следующей страницы, если она есть
 }
 
 // ListDocumentsAPI – список документов
 // @Summary Список документов
-// @Description Возвращает все документы текущего пользователя.
+// @Description Возвращает страницу документов активного пространства (личного или рабочего из X-Workspace-ID) с метаданными, без содержимого.
+// @Description Следующая страница запрашивается с cursor из next_cursor и той же сортировкой. total - количество документов по фильтрам.
+// @Description Параметр tag можно указать несколько раз: тогда возвращаются документы со всеми указанными тегами.
+// @Description from и to ограничивают дату загрузки (RFC 3339 или YYYY-MM-DD; дата без времени в to включает весь день).
 // @Tags Документы
 // @Security BearerAuth
 // @Produce json
-// @Success 200 {object} map[string]interface{} "{"documents":[]DocumentResponse}"
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param limit query int false "Размер страницы (1-200, по умолчанию 50)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param sort query string false "Сортировка: name, created_at (по умолчанию) или size; -поле - по убыванию"
+// @Param fields query string false "Поля документов через запятую: id, name, title, author, source_url, tags, fields, mime_type, size, version, created_at"
+// @Param name query string false "Имя содержит (без учёта регистра)"
+// @Param tag query []string false "Тег документа" collectionFormat(multi)
+// @Param from query string false "Загружены не раньше"
+// @Param to query string false "Загружены не позже"
+// @Param in_collection query int false "ID коллекции, в которой состоят документы"
+// @Success 200 {object} DocumentPage
+// @Failure 400 {object} map[string]string "Invalid limit, cursor, sort, fields or filter"
 // @Failure 500 {object} map[string]string "Database error"
 // @Router /api/documents [get]
 func ListDocumentsAPI(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
+	page, ok := documentPage(c, requestScope(c).where(db.DB.Model(&models.Document{}), "documents"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// documentPage - страница документов из query с фильтрами, сортировкой и курсором из параметров запроса.
+// При ошибке отвечает сама и возвращает false. Содержимое документов не загружается.
+func documentPage(c *gin.Context, query *gorm.DB) (gin.H, bool) {
+	params, err := parsePageParams(c.Query("sort"), c.Query("limit"), c.Query("cursor"), c.Query("fields"),
+		documentSorts, pagination.Sort{Field: "created_at"}, documentFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	query = query.Joins("JOIN blobs ON blobs.hash = documents.blob_hash")
+	if name := c.Query("name"); name != "" {
+		query = query.Where(`LOWER(documents.filename) LIKE ? ESCAPE '\'`, containsPattern(name))
+	}
+	tags, err := metadata.NormalizeTags(c.QueryArray("tag"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	query = withTags(query, tags)
+	if from := c.Query("from"); from != "" {
+		t, err := parseDate(from, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from: use RFC 3339 or YYYY-MM-DD"})
+			return nil, false
+		}
+		query = query.Where("documents.created_at >= ?", t)
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := parseDate(to, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to: use RFC 3339 or YYYY-MM-DD"})
+			return nil, false
+		}
+		query = query.Where("documents.created_at < ?", t)
+	}
+	if value := c.Query("in_collection"); value != "" {
+		collectionID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid in_collection"})
+			return nil, false
+		}
+		query = query.Where("documents.id IN (SELECT document_id FROM collection_documents WHERE collection_id = ?)", collectionID)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+
+	column := documentSortColumns[params.sort.Field]
+	list := query.Select("documents.*")
+	if params.cursor != nil {
+		value, err := documentCursorValue(params.sort.Field, params.cursor.Value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": pagination.ErrInvalidCursor.Error()})
+			return nil, false
+		}
+		list = afterCursor(list, column, "documents.id", params.sort, value, params.cursor.ID)
+	}
+	// Из содержимого загружаются только размер и MIME-тип
+	list = list.Preload("Blob", func(tx *gorm.DB) *gorm.DB { return tx.Select("hash", "size", "mime_type") })
+	if params.fields == nil || slices.Contains(params.fields, "tags") {
+		list = list.Preload("Tags")
+	}
 
 	var documents []models.Document
-	if err := db.DB.Where("user_id = ?", userID).Find(&documents).Error; err != nil {
+	if err := orderBy(list, column, "documents.id", params.sort).Limit(params.limit + 1).Find(&documents).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
-		return
+		return nil, false
 	}
 
-	response := make([]DocumentResponse, len(documents))
+	page := gin.H{"total": total}
+	if len(documents) > params.limit {
+		documents = documents[:params.limit]
+		last := documents[len(documents)-1]
+		page["next_cursor"] = pagination.Cursor{Sort: params.sort.String(), Value: documentSortValue(params.sort.Field, last), ID: last.ID}.Encode()
+	}
+	items := make([]interface{}, len(documents))
 	for i, doc := range documents {
-		response[i] = DocumentResponse{
-			ID:   doc.ID,
-			Name: doc.Filename,
+		items[i] = pickFields(documentResponse(doc), params.fields)
+	}
+	page["documents"] = items
+	return page, true
+}
+
+// documentSortValue - значение поля сортировки документа для курсора
+func documentSortValue(field string, doc models.Document) string {
+	switch field {
+	case "name":
+		return doc.Filename
+	case "size":
+		return strconv.FormatInt(doc.Blob.Size, 10)
+	default:
+		return doc.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// documentCursorValue - значение поля сортировки из курсора в типе столбца
+func documentCursorValue(field, value string) (interface{}, error) {
+	switch field {
+	case "name":
+		return value, nil
+	case "size":
+		return strconv.ParseInt(value, 10, 64)
+	default:
+		return time.Parse(time.RFC3339Nano, value)
+	}
+}
+
+// UpdateDocumentAPI – изменение документа
+// @Summary Переименование и метаданные документа
+// @Description Меняет имя, заголовок, автора, адрес источника, теги и дополнительные поля документа.
+// @Description Переданные поля заменяются, отсутствующие не меняются. tags заменяет все теги (теги приводятся к нижнему регистру),
+// @Description fields дополняет поля документа, значение null удаляет поле. Содержимое и версии не меняются.
+// @Description Имя очищается так же, как при загрузке; пустое после очистки имя отклоняется, занятое - 409.
+// @Tags Документы
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path int true "ID документа"
+// @Param body body UpdateDocumentRequest true "Изменения"
+// @Success 200 {object} DocumentResponse
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 409 {object} map[string]string "Document with this name already exists"
+// @Failure 500 {object} map[string]string "Failed to update document"
+// @Router /api/documents/{id} [patch]
+func UpdateDocumentAPI(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var req UpdateDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	var document models.Document
+	if err := requestScope(c).where(db.DB, "documents").Where("documents.id = ?", id).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+
+	// Изменённые поля записываются из структуры, чтобы сработал сериализатор fields
+	var columns []string
+	if req.Name != nil {
+		name := storage.CleanFilename(*req.Name)
+		if name == "" || utf8.RuneCountInString(name) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1 to 255 characters"})
+			return
+		}
+		var count int64
+		if err := requestScope(c).where(db.DB.Model(&models.Document{}), "documents").
+			Where("documents.filename = ? AND documents.id <> ?", name, document.ID).
+			Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update document"})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Document with this name already exists"})
+			return
+		}
+		document.Filename = name
+		columns = append(columns, "filename")
+	}
+	if req.Title != nil {
+		if err := metadata.CheckValue("title", *req.Title); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		document.Title = *req.Title
+		columns = append(columns, "title")
+	}
+	if req.Author != nil {
+		if err := metadata.CheckValue("author", *req.Author); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		document.Author = *req.Author
+		columns = append(columns, "author")
+	}
+	if req.SourceURL != nil {
+		if err := metadata.CheckURL(*req.SourceURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		document.SourceURL = *req.SourceURL
+		columns = append(columns, "source_url")
+	}
+	if req.Fields != nil {
+		fields, err := metadata.MergeFields(document.Fields, req.Fields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		document.Fields = fields
+		columns = append(columns, "fields")
+	}
+	var tags []string
+	if req.Tags != nil {
+		var err error
+		if tags, err = metadata.NormalizeTags(*req.Tags); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"documents": response})
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if len(columns) > 0 {
+			if err := tx.Model(&document).Select(columns).Updates(&document).Error; err != nil {
+				return err
+			}
+		}
+		if req.Tags == nil {
+			return nil
+		}
+		if err := tx.Where("document_id = ?", document.ID).Delete(&models.DocumentTag{}).Error; err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			if err := tx.Create(&models.DocumentTag{DocumentID: document.ID, Tag: tag}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Document with this name already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update document"})
+		return
+	}
+
+	db.DB.Preload("Tags").First(&document, document.ID)
+	c.JSON(http.StatusOK, documentResponse(document))
 }
 
 // GetDocumentAPI – получение документа
 // @Summary Получение документа
-// @Description Возвращает имя и содержимое документа по его ID.
+// @Description Возвращает имя, метаданные, извлечённый текст, исходную кодировку, MIME-тип и номер текущей версии документа по его ID.
 // @Tags Документы
 // @Security BearerAuth
 // @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
 // @Param id path int true "ID документа"
 // @Success 200 {object} DocumentResponse
 // @Failure 404 {object} map[string]string "Document not found"
 // @Router /api/documents/{id} [get]
 func GetDocumentAPI(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
 	id, _ := strconv.Atoi(c.Param("id"))
 
 	var document models.Document
-	if err := db.DB.Where("id = ? AND user_id = ?", id, userID).First(&document).Error; err != nil {
+	if err := requestScope(c).where(db.DB, "documents").Preload("Blob").Preload("Tags").Where("documents.id = ?", id).First(&document).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, DocumentResponse{
-		ID:      document.ID,
-		Name:    document.Filename,
-		Content: document.Content,
-	})
+	response := documentResponse(document)
+	response.Content = document.Blob.Content
+	response.Encoding = document.Blob.Encoding
+	response.MimeType = document.Blob.MimeType
+	response.Version = document.Version
+	c.JSON(http.StatusOK, response)
 }
 
 // DocumentStatisticsAPI – статистика документа
@@ -83,6 +368,7 @@ func GetDocumentAPI(c *gin.Context) {
 // @Tags Документы
 // @Security BearerAuth
 // @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
 // @Param id path int true "ID документа"
 // @Success 200 {object} map[string]interface{} "{"document_id":int,"statistics":map[string]object}"
 // @Failure 400 {object} map[string]string "Document is not in any collection"
@@ -90,11 +376,10 @@ func GetDocumentAPI(c *gin.Context) {
 // @Failure 500 {object} map[string]string "Failed to find collections"
 // @Router /api/documents/{id}/statistics [get]
 func DocumentStatisticsAPI(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
 	id, _ := strconv.Atoi(c.Param("id"))
 
 	var document models.Document
-	if err := db.DB.Where("id = ? AND user_id = ?", id, userID).First(&document).Error; err != nil {
+	if err := requestScope(c).where(db.DB, "documents").Preload("Blob").Where("documents.id = ?", id).First(&document).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
 		return
 	}
@@ -114,11 +399,13 @@ func DocumentStatisticsAPI(c *gin.Context) {
 	var allDocuments []string
 	for _, col := range collections {
 		var docs []models.Document
-		if err := db.DB.Model(&col).Association("Documents").Find(&docs); err != nil {
+		if err := db.DB.Preload("Blob").
+			Where("id IN (SELECT document_id FROM collection_documents WHERE collection_id = ?)", col.ID).
+			Find(&docs).Error; err != nil {
 			continue
 		}
 		for _, doc := range docs {
-			allDocuments = append(allDocuments, doc.ProcessedContent)
+			allDocuments = append(allDocuments, doc.Blob.ProcessedContent)
 		}
 	}
 
@@ -132,7 +419,7 @@ func DocumentStatisticsAPI(c *gin.Context) {
 		corpus = append(corpus, doc)
 	}
 
-	tf := calculation.CountTf([]string{document.ProcessedContent})
+	tf := calculation.CountTf([]string{document.Blob.ProcessedContent})
 	idf := calculation.CountIdf(corpus)
 
 	stats := make(map[string]gin.H)
@@ -188,40 +475,38 @@ func DocumentStatisticsAPI(c *gin.Context) {
 
 // DeleteDocumentAPI – удаление документа
 // @Summary Удаление документа
-// @Description Удаляет документ, все связи и физический файл.
+// @Description Перемещает документ в корзину. Членство в коллекциях сохраняется для восстановления,
+// @Description IDF коллекций пересчитывается без документа. Файлы удаляются после срока хранения корзины.
 // @Tags Документы
 // @Security BearerAuth
 // @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
 // @Param id path int true "ID документа"
-// @Success 200 {object} map[string]string "{"message":"Document deleted"}"
+// @Success 200 {object} map[string]string "{"message":"Document moved to trash"}"
 // @Failure 404 {object} map[string]string "Document not found"
 // @Failure 500 {object} map[string]string "Failed to delete document"
 // @Router /api/documents/{id} [delete]
 func DeleteDocumentAPI(c *gin.Context) {
-	userID := c.MustGet("userID").(uint)
 	id, _ := strconv.Atoi(c.Param("id"))
 
 	var document models.Document
-	if err := db.DB.Where("id = ? AND user_id = ?", id, userID).First(&document).Error; err != nil {
+	if err := requestScope(c).where(db.DB, "documents").Where("documents.id = ?", id).First(&document).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
 		return
 	}
 
-	if err := db.DB.Model(&document).Association("Collections").Clear(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear associations"})
-		return
-	}
-
+	// Перемещение в корзину: содержимое и связи с коллекциями удаляются только при очистке корзины
 	if err := db.DB.Delete(&document).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Delete failed"})
 		return
 	}
 
-	if err := os.Remove(document.OriginalPath); err != nil {
-		log.Printf("Failed to remove file: %v", err)
+	if err := recalcDocumentCollections(c.Request.Context(), []uint{document.ID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update IDF"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Document deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "Document moved to trash"})
 }
 
 // HuffmanEncodeAPI – кодирование Хаффмана
@@ -230,6 +515,7 @@ func DeleteDocumentAPI(c *gin.Context) {
 // @Tags Документы
 // @Security BearerAuth
 // @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
 // @Param id path int true "ID документа"
 // @Success 200 {object} map[string]interface{} "{"document_id":int,"huffman_encoded":string}"
 // @Failure 400 {object} map[string]string "Invalid document ID or content too large"
@@ -238,25 +524,24 @@ func DeleteDocumentAPI(c *gin.Context) {
 // @Router /api/documents/{id}/huffman [get]
 func HuffmanEncodeAPI(c *gin.Context) {
 	documentID := c.Param("id")
-	userID := c.MustGet("userID").(uint)
 
 	id, err := strconv.Atoi(documentID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
 	}
 	var document models.Document
-	if err := db.DB.Where("id = ? AND user_id = ?", id, userID).First(&document).Error; err != nil {
+	if err := requestScope(c).where(db.DB, "documents").Preload("Blob").Where("documents.id = ?", id).First(&document).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
 		return
 	}
 
 	const maxSize = 10 * 1024 * 1024 // ограничение по памяти документа (10MB)
-	if len(document.Content) > maxSize {
+	if len(document.Blob.Content) > maxSize {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Document content too large"})
 		return
 	}
 
-	encodedContent, err := calculation.Encode(document.Content)
+	encodedContent, err := calculation.Encode(document.Blob.Content)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode document"})
 		return
@@ -267,3 +552,56 @@ func HuffmanEncodeAPI(c *gin.Context) {
 		"huffman_encoded": encodedContent,
 	})
 }
+
+// documentResponse - имя и метаданные документа без содержимого. Теги и размер берутся из загруженных Tags и Blob.
+func documentResponse(doc models.Document) DocumentResponse {
+	tags := make([]string, len(doc.Tags))
+	for i, tag := range doc.Tags {
+		tags[i] = tag.Tag
+	}
+	sort.Strings(tags)
+	response := DocumentResponse{
+		ID:        doc.ID,
+		Name:      doc.Filename,
+		Title:     doc.Title,
+		Author:    doc.Author,
+		SourceURL: doc.SourceURL,
+		Tags:      tags,
+		Fields:    doc.Fields,
+		Version:   doc.Version,
+		CreatedAt: doc.CreatedAt,
+	}
+	if doc.Blob != nil {
+		response.MimeType = doc.Blob.MimeType
+		response.Size = doc.Blob.Size
+	}
+	return response
+}
+
+// withTags - отбор документов, у которых есть все указанные теги
+func withTags(query *gorm.DB, tags []string) *gorm.DB {
+	if len(tags) == 0 {
+		return query
+	}
+	return query.Where("documents.id IN (SELECT document_id FROM document_tags WHERE tag IN ? GROUP BY document_id HAVING COUNT(*) = ?)",
+		tags, len(tags))
+}
+
+// parseDate - дата из параметра запроса в формате RFC 3339 или YYYY-MM-DD.
+// Для верхней границы дата без времени означает начало следующего дня.
+func parseDate(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		if end {
+			t = t.Add(time.Nanosecond)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return t, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/jobs"
+	"LestaStartTest/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Период чтения прогресса задачи из БД для потока событий
+const defaultJobEventsPollInterval = 2 * time.Second
+
+// JobResponse - состояние фоновой задачи
+type JobResponse struct {
+	ID         uint            `json:"id"`
+	Type       string          `json:"type"`
+	Status     string          `json:"status" enums:"queued,running,succeeded,failed"`
+	Total      int             `json:"total"`
+	Processed  int             `json:"processed"`
+	Errors     []string        `json:"errors"`                                // ошибки отдельных файлов
+	Error      string          `json:"error,omitempty"`                       // причина неудачи задачи
+	Result     json.RawMessage `json:"result,omitempty" swaggertype:"object"` // для загрузки - UploadResult
+	CreatedAt  time.Time       `json:"created_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// GetJobAPI – состояние фоновой задачи
+// @Summary Статус задачи
+// @Description Возвращает статус, прогресс, ошибки отдельных файлов и результат фоновой задачи.
+// @Description Задачи хранятся 7 дней после завершения (JOB_RETENTION).
+// @Description Если задача выполнена, но часть файлов не обработана, возвращается 207 Multi-Status: ошибки в errors, итог по каждому файлу в result.files.
+// @Tags Задачи
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID задачи"
+// @Success 200 {object} JobResponse
+// @Success 207 {object} JobResponse "Задача выполнена частично"
+// @Failure 404 {object} map[string]string "Job not found"
+// @Router /api/jobs/{id} [get]
+func GetJobAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var job models.Job
+	if err := db.DB.Where("id = ? AND user_id = ?", id, userID).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+
+	status := http.StatusOK
+	if job.Status == jobs.StatusSucceeded && len(job.Errors) > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, jobResponse(job))
+}
+
+// JobEventsAPI – поток событий фоновой задачи
+// @Summary События задачи (SSE)
+// @Description Поток Server-Sent Events с прогрессом задачи. Первое событие описывает текущее состояние.
+// @Description События progress приходят после каждого файла (stage=files) и по мере пересчёта IDF коллекций (stage=idf),
+// @Description поток закрывается после события completed или failed. Если задача выполняется другим экземпляром приложения,
+// @Description прогресс читается из БД раз в JOB_EVENTS_POLL_INTERVAL (по умолчанию 2s).
+// @Tags Задачи
+// @Security BearerAuth
+// @Produce text/event-stream
+// @Param id path int true "ID задачи"
+// @Success 200 {object} jobs.Event "Поток событий progress, completed и failed"
+// @Failure 404 {object} map[string]string "Job not found"
+// @Router /api/jobs/{id}/events [get]
+func JobEventsAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var job models.Job
+	if err := db.DB.Where("id = ? AND user_id = ?", id, userID).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+
+	// Подписка до отправки текущего состояния, чтобы не пропустить события между ними
+	events, cancel := jobs.Subscribe(job.ID)
+	defer cancel()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // отключение буферизации в nginx
+
+	last := jobs.EventFor(job)
+	c.SSEvent(last.Type, last)
+	if last.Type != jobs.EventProgress {
+		return
+	}
+
+	ticker := time.NewTicker(durationFromEnv("JOB_EVENTS_POLL_INTERVAL", defaultJobEventsPollInterval))
+	defer ticker.Stop()
+	local := false // задача выполняется этим экземпляром и события приходят по подписке
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event := <-events:
+			local = true
+			c.SSEvent(event.Type, event)
+			return event.Type == jobs.EventProgress
+		case <-ticker.C:
+			// Резервный путь: задачу может выполнять другой экземпляр приложения,
+			// а итоговое событие может быть пропущено переполненной подпиской
+			if err := db.DB.First(&job, job.ID).Error; err != nil {
+				return false
+			}
+			current := jobs.EventFor(job)
+			if current.Type == jobs.EventProgress && (local || current.Processed == last.Processed) {
+				io.WriteString(w, ": keepalive\n\n")
+				return true
+			}
+			last = current
+			c.SSEvent(current.Type, current)
+			return current.Type == jobs.EventProgress
+		}
+	})
+}
+
+// StartJobs - регистрация обработчиков фоновых задач и запуск очереди
+func StartJobs(ctx context.Context) {
+	jobs.Register(jobUpload, processUpload)
+	jobs.Start(ctx)
+}
+
+func jobResponse(job models.Job) JobResponse {
+	response := JobResponse{
+		ID:         job.ID,
+		Type:       job.Type,
+		Status:     job.Status,
+		Total:      job.Total,
+		Processed:  job.Processed,
+		Errors:     job.Errors,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+	}
+	if response.Errors == nil {
+		response.Errors = []string{}
+	}
+	if job.Result != "" {
+		response.Result = json.RawMessage(job.Result)
+	}
+	return response
+}
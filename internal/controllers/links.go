@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ограничение количества публичных ссылок на одну коллекцию
+const maxShareLinksPerCollection = 20
+
+// CreateShareLinkRequest - параметры публичной ссылки
+type CreateShareLinkRequest struct {
+	AllowContent bool       `json:"allow_content"` // разрешить получение текста документов
+	ExpiresAt    *time.Time `json:"expires_at"`    // по умолчанию ссылка бессрочная
+}
+
+// ShareLinkResponse - публичная ссылка без токена
+type ShareLinkResponse struct {
+	ID           uint       `json:"id"`
+	Prefix       string     `json:"prefix"` // начало токена
+	AllowContent bool       `json:"allow_content"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CreateShareLinkResponse - созданная ссылка; token и url возвращаются только в этом ответе
+type CreateShareLinkResponse struct {
+	ShareLinkResponse
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// CreateShareLinkAPI – создание публичной ссылки
+// @Summary Создание публичной ссылки на коллекцию
+// @Description Создаёт ссылку, по которой без аккаунта доступны коллекция, список её документов и TF-IDF статистика.
+// @Description Текст документов доступен, только если указано allow_content. Токен показывается один раз, сохраняется только его хэш.
+// @Description Доступно только владельцу; для коллекции рабочего пространства - только владельцу пространства, не редактору.
+// @Tags Коллекции
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path int true "ID коллекции"
+// @Param body body CreateShareLinkRequest false "Доступ к тексту и срок действия"
+// @Success 201 {object} CreateShareLinkResponse
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Collection access is not enough"
+// @Failure 404 {object} map[string]string "Collection not found"
+// @Failure 409 {object} map[string]string "Too many share links"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/collections/{id}/links [post]
+func CreateShareLinkAPI(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var req CreateShareLinkRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+	collection, _, ok := findCollection(c, id, models.AccessOwner)
+	if !ok {
+		return
+	}
+
+	var count int64
+	if err := db.DB.Model(&models.ShareLink{}).Where("collection_id = ?", collection.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if count >= maxShareLinksPerCollection {
+		c.JSON(http.StatusConflict, gin.H{"error": "Too many share links, revoke unused ones first"})
+		return
+	}
+
+	token := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	link := models.ShareLink{
+		CollectionID: collection.ID,
+		Prefix:       token[:8],
+		TokenHash:    hashToken(token),
+		AllowContent: req.AllowContent,
+		ExpiresAt:    req.ExpiresAt,
+	}
+	if err := db.DB.Create(&link).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateShareLinkResponse{
+		ShareLinkResponse: shareLinkResponse(link),
+		Token:             token,
+		URL:               "/public/collections/" + token,
+	})
+}
+
+// ListShareLinksAPI – публичные ссылки коллекции
+// @Summary Публичные ссылки коллекции
+// @Description Возвращает ссылки коллекции с правами и сроком действия. Сами токены не возвращаются.
+// @Description Доступно только владельцу; для коллекции рабочего пространства - только владельцу пространства, не редактору.
+// @Tags Коллекции
+// @Security BearerAuth
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path int true "ID коллекции"
+// @Success 200 {array} ShareLinkResponse
+// @Failure 403 {object} map[string]string "Collection access is not enough"
+// @Failure 404 {object} map[string]string "Collection not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/collections/{id}/links [get]
+func ListShareLinksAPI(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	collection, _, ok := findCollection(c, id, models.AccessOwner)
+	if !ok {
+		return
+	}
+
+	var links []models.ShareLink
+	if err := db.DB.Where("collection_id = ?", collection.ID).Order("created_at DESC").Find(&links).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	response := make([]ShareLinkResponse, len(links))
+	for i, link := range links {
+		response[i] = shareLinkResponse(link)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteShareLinkAPI – отзыв публичной ссылки
+// @Summary Отзыв публичной ссылки
+// @Description Удаляет ссылку, запросы по ней сразу перестают приниматься.
+// @Description Доступно только владельцу; для коллекции рабочего пространства - только владельцу пространства, не редактору.
+// @Tags Коллекции
+// @Security BearerAuth
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path int true "ID коллекции"
+// @Param link_id path int true "ID ссылки"
+// @Success 200 {object} map[string]string "{"message":"Share link revoked"}"
+// @Failure 403 {object} map[string]string "Collection access is not enough"
+// @Failure 404 {object} map[string]string "Collection or share link not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/collections/{id}/links/{link_id} [delete]
+func DeleteShareLinkAPI(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	linkID, _ := strconv.Atoi(c.Param("link_id"))
+
+	collection, _, ok := findCollection(c, id, models.AccessOwner)
+	if !ok {
+		return
+	}
+
+	res := db.DB.Where("id = ? AND collection_id = ?", linkID, collection.ID).Delete(&models.ShareLink{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked"})
+}
+
+// findShareLink - действующая ссылка по токену из пути и её коллекция. Отозванная, просроченная ссылка,
+// коллекция в корзине и заблокированный владелец личной коллекции неотличимы от несуществующей ссылки.
+func findShareLink(c *gin.Context) (models.ShareLink, models.Collection, bool) {
+	// Токен в адресе не должен попадать в кэши и заголовок Referer сторонних сайтов
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+
+	var link models.ShareLink
+	var collection models.Collection
+	err := db.DB.Where("token_hash = ?", hashToken(c.Param("token"))).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		First(&link).Error
+	if err == nil {
+		err = db.DB.Where("(collections.workspace_id IS NOT NULL OR collections.user_id IN (SELECT id FROM users WHERE disabled = ?))", false).
+			First(&collection, link.CollectionID).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		return link, collection, false
+	}
+	return link, collection, true
+}
+
+// PublicCollectionAPI – коллекция по публичной ссылке
+// @Summary Коллекция по публичной ссылке
+// @Description Возвращает имя коллекции и страницу её документов без содержимого. Авторизация не нужна.
+// @Description Параметры страницы, сортировки, полей и фильтров документов такие же, как у GET /api/documents.
+// @Tags Публичные ссылки
+// @Produce json
+// @Param token path string true "Токен ссылки"
+// @Param limit query int false "Размер страницы документов (1-200, по умолчанию 50)"
+// @Param cursor query string false "Курсор следующей страницы документов"
+// @Param sort query string false "Сортировка документов: name, created_at (по умолчанию) или size; -поле - по убыванию"
+// @Param fields query string false "Поля документов через запятую"
+// @Success 200 {object} map[string]interface{} "{"name":string,"created_at":string,"allow_content":bool,"documents":[]DocumentResponse,"total":int,"next_cursor":string}"
+// @Failure 400 {object} map[string]string "Invalid limit, cursor, sort, fields or filter"
+// @Failure 404 {object} map[string]string "Share link not found"
+// @Router /public/collections/{token} [get]
+func PublicCollectionAPI(c *gin.Context) {
+	link, collection, ok := findShareLink(c)
+	if !ok {
+		return
+	}
+
+	page, ok := documentPage(c, db.DB.Model(&models.Document{}).
+		Where("documents.id IN (SELECT document_id FROM collection_documents WHERE collection_id = ?)", collection.ID))
+	if !ok {
+		return
+	}
+	page["name"] = collection.Name
+	page["created_at"] = collection.CreatedAt
+	page["allow_content"] = link.AllowContent
+	c.JSON(http.StatusOK, page)
+}
+
+// PublicCollectionStatisticsAPI – статистика коллекции по публичной ссылке
+// @Summary TF‑IDF статистика коллекции по публичной ссылке
+// @Description Рассчитывает TF‑IDF внутри всех документов коллекции, как GET /api/collections/{id}/statistics. Авторизация не нужна.
+// @Tags Публичные ссылки
+// @Produce json
+// @Param token path string true "Токен ссылки"
+// @Success 200 {object} map[string]interface{} "{"statistics":map[string]object}"
+// @Failure 404 {object} map[string]string "Share link not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /public/collections/{token}/statistics [get]
+func PublicCollectionStatisticsAPI(c *gin.Context) {
+	_, collection, ok := findShareLink(c)
+	if !ok {
+		return
+	}
+	result, err := collectionStatistics(collection.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"statistics": result})
+}
+
+// PublicDocumentAPI – документ коллекции по публичной ссылке
+// @Summary Документ коллекции по публичной ссылке
+// @Description Возвращает документ коллекции с извлечённым текстом, если ссылка создана с allow_content. Авторизация не нужна.
+// @Tags Публичные ссылки
+// @Produce json
+// @Param token path string true "Токен ссылки"
+// @Param document_id path int true "ID документа"
+// @Success 200 {object} DocumentResponse
+// @Failure 403 {object} map[string]string "Share link does not allow document content"
+// @Failure 404 {object} map[string]string "Share link or document not found"
+// @Router /public/collections/{token}/documents/{document_id} [get]
+func PublicDocumentAPI(c *gin.Context) {
+	link, collection, ok := findShareLink(c)
+	if !ok {
+		return
+	}
+	if !link.AllowContent {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Share link does not allow document content"})
+		return
+	}
+	documentID, _ := strconv.Atoi(c.Param("document_id"))
+
+	var document models.Document
+	if err := db.DB.Preload("Blob").Preload("Tags").
+		Where("id = ? AND id IN (SELECT document_id FROM collection_documents WHERE collection_id = ?)", documentID, collection.ID).
+		First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+
+	response := documentResponse(document)
+	response.Content = document.Blob.Content
+	response.Encoding = document.Blob.Encoding
+	c.JSON(http.StatusOK, response)
+}
+
+func shareLinkResponse(link models.ShareLink) ShareLinkResponse {
+	return ShareLinkResponse{
+		ID:           link.ID,
+		Prefix:       link.Prefix,
+		AllowContent: link.AllowContent,
+		ExpiresAt:    link.ExpiresAt,
+		CreatedAt:    link.CreatedAt,
+	}
+}
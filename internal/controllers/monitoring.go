@@ -22,10 +22,12 @@ func StatusHandler(c *gin.Context) {
 
 // MetricsHandler – метрики приложения
 // @Summary Метрики обработки документов
-// @Description Возвращает общее число обработанных документов и среднее время обработки (нс).
+// @Description Возвращает общее число обработанных документов и среднее время обработки (нс). Доступно администраторам.
 // @Tags Системные
+// @Security BearerAuth
 // @Produce json
 // @Success 200 {object} map[string]string "Application metrics"
+// @Failure 403 {object} map[string]string "Forbidden"
 // @Router /api/metrics [get]
 func MetricsHandler(c *gin.Context) {
 	totalDocs, avgTime := monitoring.GetMetrics()
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"LestaStartTest/internal/pagination"
+
+	"gorm.io/gorm"
+)
+
+// pageParams - параметры страницы списка: сортировка, размер, курсор и поля ответа
+type pageParams struct {
+	sort   pagination.Sort
+	limit  int
+	cursor *pagination.Cursor
+	fields []string
+}
+
+// parsePageParams - разбор параметров sort, limit, cursor и fields
+func parsePageParams(sortValue, limitValue, cursorValue, fieldsValue string, sorts []string, def pagination.Sort, fields []string) (pageParams, error) {
+	var params pageParams
+	var err error
+	if params.sort, err = pagination.ParseSort(sortValue, sorts, def); err != nil {
+		return params, err
+	}
+	if params.limit, err = pagination.ParseLimit(limitValue); err != nil {
+		return params, err
+	}
+	if cursorValue != "" {
+		cursor, err := pagination.DecodeCursor(cursorValue, params.sort)
+		if err != nil {
+			return params, err
+		}
+		params.cursor = &cursor
+	}
+	params.fields, err = pagination.ParseFields(fieldsValue, fields)
+	return params, err
+}
+
+// afterCursor - строки после курсора при сортировке по column и затем по idColumn в том же направлении
+func afterCursor(query *gorm.DB, column, idColumn string, sort pagination.Sort, value interface{}, id uint) *gorm.DB {
+	op := ">"
+	if sort.Desc {
+		op = "<"
+	}
+	return query.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", column, op, column, idColumn, op), value, value, id)
+}
+
+// orderBy - порядок строк страницы: по column, при равенстве - по idColumn
+func orderBy(query *gorm.DB, column, idColumn string, sort pagination.Sort) *gorm.DB {
+	dir := " ASC"
+	if sort.Desc {
+		dir = " DESC"
+	}
+	return query.Order(column + dir).Order(idColumn + dir)
+}
+
+// containsPattern - шаблон LIKE для поиска подстроки без учёта регистра; спецсимволы LIKE экранируются
+func containsPattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(s))
+	return "%" + s + "%"
+}
+
+// pickFields - только запрошенные поля ответа; без списка полей объект возвращается целиком
+func pickFields(v interface{}, fields []string) interface{} {
+	if fields == nil {
+		return v
+	}
+	data, _ := json.Marshal(v)
+	var all map[string]interface{}
+	json.Unmarshal(data, &all)
+	picked := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			picked[field] = value
+		}
+	}
+	return picked
+}
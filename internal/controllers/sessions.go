@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/middleware"
+	"LestaStartTest/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SessionResponse - активный вход в аккаунт
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"` // когда сессия завершится, если не обновлять токены
+	Current    bool      `json:"current"`    // сессия, которой принадлежит токен запроса
+}
+
+// ListSessionsAPI – активные сессии
+// @Summary Активные сессии
+// @Description Возвращает входы в аккаунт, которые ещё действуют: время входа и последнего обращения, IP и клиент.
+// @Description Последнее обращение обновляется не чаще раза в минуту.
+// @Tags Пользователь
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} SessionResponse
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/user/sessions [get]
+func ListSessionsAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	claims := c.MustGet("claims").(*middleware.Claims)
+
+	var sessions []models.Session
+	if err := db.DB.Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	response := make([]SessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = SessionResponse{
+			ID:         session.ID,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == claims.SessionID,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteSessionAPI – завершение сессии
+// @Summary Завершение сессии
+// @Description Завершает вход в аккаунт, например на потерянном устройстве: его access-токены перестают действовать сразу,
+// @Description refresh-токены отзываются.
+// @Tags Пользователь
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Success 200 {object} map[string]string "{"message":"Session revoked"}"
+// @Failure 404 {object} map[string]string "Session not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/user/sessions/{id} [delete]
+func DeleteSessionAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	found, err := endSession(db.DB, userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// startSession - новая сессия для клиента запроса и первая пара её токенов (вход в аккаунт)
+func startSession(c *gin.Context, tx *gorm.DB, userID uint) (TokenResponse, error) {
+	var tokens TokenResponse
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		session := models.Session{
+			ID:         randomToken(16, hex.EncodeToString),
+			UserID:     userID,
+			IP:         c.ClientIP(),
+			UserAgent:  middleware.TruncateUserAgent(c.Request.UserAgent()),
+			LastUsedAt: now,
+			ExpiresAt:  now.Add(refreshTokenTTL()),
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		var err error
+		tokens, err = issueTokens(tx, userID, session.ID)
+		return err
+	})
+	return tokens, err
+}
+
+// renewSession - продление сессии при обновлении токенов. Цепочки refresh-токенов,
+// выданных до появления сессий, получают сессию с тем же ID.
+func renewSession(c *gin.Context, tx *gorm.DB, userID uint, sessionID string) error {
+	now := time.Now()
+	session := models.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		IP:         c.ClientIP(),
+		UserAgent:  middleware.TruncateUserAgent(c.Request.UserAgent()),
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL()),
+	}
+	res := tx.Model(&models.Session{}).Where("id = ? AND user_id = ?", sessionID, userID).Updates(map[string]interface{}{
+		"ip":           session.IP,
+		"user_agent":   session.UserAgent,
+		"last_used_at": session.LastUsedAt,
+		"expires_at":   session.ExpiresAt,
+	})
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error
+	}
+	return tx.Create(&session).Error
+}
+
+// endSession - удаление сессии пользователя вместе с её refresh-токенами. Access-токены сессии
+// отклоняются middleware, так как сессии больше нет. Возвращает false, если сессия не найдена.
+func endSession(tx *gorm.DB, userID uint, sessionID string) (bool, error) {
+	var found bool
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND user_id = ?", sessionID, userID).Delete(&models.Session{})
+		if res.Error != nil {
+			return res.Error
+		}
+		found = res.RowsAffected > 0
+		return tx.Where("family = ? AND user_id = ?", sessionID, userID).Delete(&models.RefreshToken{}).Error
+	})
+	return found, err
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ShareCollectionRequest - пользователь и уровень его доступа к коллекции
+type ShareCollectionRequest struct {
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role" binding:"required,oneof=viewer editor"`
+}
+
+// CollectionShareResponse - участник коллекции
+type CollectionShareResponse struct {
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// accessRank - порядок уровней доступа: владелец может всё, что редактор, редактор - всё, что читатель
+func accessRank(access string) int {
+	switch access {
+	case models.AccessViewer:
+		return 1
+	case models.AccessEditor:
+		return 2
+	case models.AccessOwner:
+		return 3
+	}
+	return 0
+}
+
+// managesCollection - может ли пользователь удалить коллекцию и убрать из неё любой документ: владелец,
+// а для коллекции активного рабочего пространства - также редактор (коллекции пространства общие)
+func managesCollection(collection models.Collection, access string, scope ownerScope) bool {
+	return access == models.AccessOwner ||
+		access == models.AccessEditor && scope.WorkspaceID != nil && scope.owns(collection.UserID, collection.WorkspaceID)
+}
+
+// collectionAccess - уровень доступа к коллекции: owner, editor, viewer или пустая строка.
+// К коллекциям активного пространства доступ определяется ролью в нём, к остальным - совместным доступом.
+func collectionAccess(collection models.Collection, scope ownerScope) (string, error) {
+	if scope.owns(collection.UserID, collection.WorkspaceID) {
+		return scope.collectionAccess(), nil
+	}
+	var share models.CollectionShare
+	err := db.DB.Where("collection_id = ? AND user_id = ?", collection.ID, scope.UserID).First(&share).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	return share.Role, err
+}
+
+// findCollection - коллекция, к которой у пользователя есть доступ не ниже need.
+// Чужая коллекция без доступа не отличается от несуществующей; при недостаточном доступе ответ 403.
+func findCollection(c *gin.Context, id int, need string) (models.Collection, string, bool) {
+	var collection models.Collection
+	if err := db.DB.First(&collection, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return collection, "", false
+	}
+	access, err := collectionAccess(collection, requestScope(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return collection, "", false
+	}
+	if access == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return collection, "", false
+	}
+	if accessRank(access) < accessRank(need) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Collection access " + access + " is not enough, " + need + " required"})
+		return collection, "", false
+	}
+	return collection, access, true
+}
+
+// ShareCollectionAPI – предоставление доступа к коллекции
+// @Summary Предоставление доступа к коллекции
+// @Description Даёт другому пользователю доступ к коллекции: viewer - просмотр документов и статистики,
+// @Description editor - также добавление в коллекцию своих документов. Повторный вызов меняет уровень доступа.
+// @Description Доступно только владельцу; для коллекции рабочего пространства - только владельцу пространства, не редактору.
+// @Tags Коллекции
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path int true "ID коллекции"
+// @Param body body ShareCollectionRequest true "Пользователь и уровень доступа"
+// @Success 201 {object} CollectionShareResponse
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Collection access is not enough"
+// @Failure 404 {object} map[string]string "Collection or user not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/collections/{id}/shares [post]
+func ShareCollectionAPI(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var req ShareCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	collection, _, ok := findCollection(c, id, models.AccessOwner)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	if err := db.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.ID == collection.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Collection owner already has full access"})
+		return
+	}
+
+	share := models.CollectionShare{CollectionID: collection.ID, UserID: user.ID, Role: req.Role}
+	if err := db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "collection_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(&share).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CollectionShareResponse{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      share.Role,
+		CreatedAt: share.CreatedAt,
+	})
+}
+
+// ListCollectionSharesAPI – участники коллекции
+// @Summary Участники коллекции
+// @Description Возвращает пользователей, которым владелец предоставил доступ к коллекции. Доступно владельцу и участникам.
+// @Tags Коллекции
+// @Security BearerAuth
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path int true "ID коллекции"
+// @Success 200 {object} map[string]interface{} "{"owner_id":int,"shares":[]CollectionShareResponse}"
+// @Failure 404 {object} map[string]string "Collection not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/collections/{id}/shares [get]
+func ListCollectionSharesAPI(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	collection, _, ok := findCollection(c, id, models.AccessViewer)
+	if !ok {
+		return
+	}
+
+	shares := []CollectionShareResponse{}
+	if err := db.DB.Table("collection_shares").
+		Select("collection_shares.user_id, users.username, collection_shares.role, collection_shares.created_at").
+		Joins("JOIN users ON users.id = collection_shares.user_id").
+		Where("collection_shares.collection_id = ?", collection.ID).
+		Order("users.username").
+		Scan(&shares).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"owner_id": collection.UserID, "shares": shares})
+}
+
+// DeleteCollectionShareAPI – отзыв доступа к коллекции
+// @Summary Отзыв доступа к коллекции
+// @Description Владелец отзывает доступ любого участника, участник может выйти из коллекции сам.
+// @Description Личные документы участника убираются из коллекции, документы рабочих пространств остаются; IDF пересчитывается.
+// @Tags Коллекции
+// @Security BearerAuth
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path int true "ID коллекции"
+// @Param user_id path int true "ID участника"
+// @Success 200 {object} map[string]string "{"message":"Access revoked"}"
+// @Failure 403 {object} map[string]string "Only the owner can revoke access of other members"
+// @Failure 404 {object} map[string]string "Collection or share not found"
+// @Failure 500 {object} map[string]string "Failed to revoke access or update IDF"
+// @Router /api/collections/{id}/shares/{user_id} [delete]
+func DeleteCollectionShareAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	memberID, _ := strconv.Atoi(c.Param("user_id"))
+
+	collection, access, ok := findCollection(c, id, models.AccessViewer)
+	if !ok {
+		return
+	}
+	if access != models.AccessOwner && uint(memberID) != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can revoke access of other members"})
+		return
+	}
+
+	tx := db.DB.Begin()
+	res := tx.Where("collection_id = ? AND user_id = ?", collection.ID, memberID).Delete(&models.CollectionShare{})
+	if res.Error == nil && res.RowsAffected == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share not found"})
+		return
+	}
+	err := res.Error
+	if err == nil {
+		err = tx.Exec(`DELETE FROM collection_documents WHERE collection_id = ?
+			AND document_id IN (SELECT id FROM documents WHERE user_id = ? AND workspace_id IS NULL)`, collection.ID, memberID).Error
+	}
+	if err == nil {
+		err = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke access"})
+		return
+	}
+
+	if err := recalcCollectionIDF(c.Request.Context(), collection.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update IDF"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Access revoked"})
+}
@@ -0,0 +1,287 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Срок хранения корзины и период очистки по умолчанию
+const (
+	defaultTrashRetention     = 30 * 24 * time.Hour
+	defaultTrashPurgeInterval = time.Hour
+)
+
+// TrashItem - документ или коллекция в корзине
+type TrashItem struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"` // время окончательного удаления
+}
+
+// TrashAPI – содержимое корзины
+// @Summary Корзина
+// @Description Возвращает удалённые документы и коллекции активного пространства, которые ещё можно восстановить.
+// @Tags Корзина
+// @Security BearerAuth
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Success 200 {object} map[string]interface{} "{"documents":[]TrashItem,"collections":[]TrashItem}"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/trash [get]
+func TrashAPI(c *gin.Context) {
+	scope := requestScope(c)
+	retention := trashRetention()
+
+	var documents []models.Document
+	if err := scope.where(db.DB.Unscoped(), "documents").
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&documents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var collections []models.Collection
+	if err := scope.where(db.DB.Unscoped(), "collections").
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&collections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	documentItems := make([]TrashItem, len(documents))
+	for i, doc := range documents {
+		documentItems[i] = TrashItem{
+			ID:        doc.ID,
+			Name:      doc.Filename,
+			DeletedAt: doc.DeletedAt.Time,
+			PurgeAt:   doc.DeletedAt.Time.Add(retention),
+		}
+	}
+	collectionItems := make([]TrashItem, len(collections))
+	for i, col := range collections {
+		collectionItems[i] = TrashItem{
+			ID:        col.ID,
+			Name:      col.Name,
+			DeletedAt: col.DeletedAt.Time,
+			PurgeAt:   col.DeletedAt.Time.Add(retention),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents":   documentItems,
+		"collections": collectionItems,
+	})
+}
+
+// RestoreAPI – восстановление из корзины
+// @Summary Восстановление из корзины
+// @Description Восстанавливает документ или коллекцию. Документ возвращается во все коллекции, в которых состоял,
+// @Description и получает новое имя, если исходное уже занято. IDF затронутых коллекций пересчитывается.
+// @Tags Корзина
+// @Security BearerAuth
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param type path string true "Тип объекта" Enums(documents, collections)
+// @Param id path int true "ID документа или коллекции"
+// @Success 200 {object} map[string]interface{} "{"message":string,"id":int,"name":string}"
+// @Failure 400 {object} map[string]string "Unknown type"
+// @Failure 404 {object} map[string]string "Not found in trash"
+// @Failure 500 {object} map[string]string "Failed to restore"
+// @Router /api/trash/{type}/{id}/restore [post]
+func RestoreAPI(c *gin.Context) {
+	scope := requestScope(c)
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	switch c.Param("type") {
+	case "documents":
+		var document models.Document
+		if err := scope.where(db.DB.Unscoped(), "documents").
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			First(&document).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document not found in trash"})
+			return
+		}
+
+		// Пока документ был в корзине, его имя могли занять
+		var count int64
+		if err := scope.where(db.DB.Model(&models.Document{}), "documents").
+			Where("filename = ?", document.Filename).
+			Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if count > 0 {
+			name, err := freeFilename(scope, document.Filename, nil)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+				return
+			}
+			document.Filename = name
+		}
+
+		if err := db.DB.Unscoped().Model(&document).
+			Updates(map[string]interface{}{"deleted_at": nil, "filename": document.Filename}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore document"})
+			return
+		}
+		if err := recalcDocumentCollections(c.Request.Context(), []uint{document.ID}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update IDF"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Document restored", "id": document.ID, "name": document.Filename})
+
+	case "collections":
+		var collection models.Collection
+		if err := scope.where(db.DB.Unscoped(), "collections").
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			First(&collection).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found in trash"})
+			return
+		}
+
+		if err := db.DB.Unscoped().Model(&collection).Update("deleted_at", nil).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore collection"})
+			return
+		}
+		// IDF удалённой коллекции не хранится, а состав документов мог измениться
+		if err := recalcCollectionIDF(c.Request.Context(), collection.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update IDF"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Collection restored", "id": collection.ID, "name": collection.Name})
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be documents or collections"})
+	}
+}
+
+// StartTrashPurge - фоновая очистка корзины каждые TRASH_PURGE_INTERVAL
+func StartTrashPurge(ctx context.Context) {
+	interval := durationFromEnv("TRASH_PURGE_INTERVAL", defaultTrashPurgeInterval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := PurgeTrash(ctx, time.Now().Add(-trashRetention())); err != nil {
+				log.Printf("Trash purge failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// PurgeTrash - окончательное удаление документов и коллекций, попавших в корзину раньше before
+func PurgeTrash(ctx context.Context, before time.Time) error {
+	var documentIDs, collectionIDs []uint
+	if err := db.DB.Unscoped().Model(&models.Document{}).
+		Where("deleted_at < ?", before).
+		Pluck("id", &documentIDs).Error; err != nil {
+		return err
+	}
+	if err := db.DB.Unscoped().Model(&models.Collection{}).
+		Where("deleted_at < ?", before).
+		Pluck("id", &collectionIDs).Error; err != nil {
+		return err
+	}
+	if len(documentIDs) == 0 && len(collectionIDs) == 0 {
+		return nil
+	}
+
+	var staleKeys []string
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if staleKeys, err = purgeDocuments(tx, documentIDs); err != nil {
+			return err
+		}
+		return purgeCollections(tx, collectionIDs)
+	})
+	if err != nil {
+		return err
+	}
+	db.RemoveBlobFiles(ctx, staleKeys)
+
+	log.Printf("Trash purged: %d documents, %d collections", len(documentIDs), len(collectionIDs))
+	return nil
+}
+
+// purgeDocuments - окончательное удаление документов с версиями и связями с коллекциями.
+// Возвращает ключи файлов, на которые больше никто не ссылается.
+func purgeDocuments(tx *gorm.DB, documentIDs []uint) ([]string, error) {
+	if len(documentIDs) == 0 {
+		return nil, nil
+	}
+	staleKeys, err := releaseDocuments(tx, documentIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Exec("DELETE FROM collection_documents WHERE document_id IN ?", documentIDs).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("document_id IN ?", documentIDs).Delete(&models.DocumentVersion{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("document_id IN ?", documentIDs).Delete(&models.DocumentTag{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Unscoped().Delete(&models.Document{}, documentIDs).Error; err != nil {
+		return nil, err
+	}
+	return staleKeys, nil
+}
+
+// purgeCollections - окончательное удаление коллекций вместе с IDF и связями с документами
+func purgeCollections(tx *gorm.DB, collectionIDs []uint) error {
+	if len(collectionIDs) == 0 {
+		return nil
+	}
+	if err := tx.Exec("DELETE FROM collection_documents WHERE collection_id IN ?", collectionIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("collection_id IN ?", collectionIDs).Delete(&models.CollectionIDF{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("collection_id IN ?", collectionIDs).Delete(&models.CollectionShare{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("collection_id IN ?", collectionIDs).Delete(&models.ShareLink{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&models.Collection{}, collectionIDs).Error
+}
+
+// trashRetention - срок хранения корзины из TRASH_RETENTION (по умолчанию 30 дней)
+func trashRetention() time.Duration {
+	return durationFromEnv("TRASH_RETENTION", defaultTrashRetention)
+}
+
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", name, v, def)
+		return def
+	}
+	return d
+}
@@ -0,0 +1,494 @@
+package controllers
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/middleware"
+	"LestaStartTest/internal/models"
+	"LestaStartTest/internal/ratelimit"
+	"LestaStartTest/internal/totp"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Параметры второго шага входа и кодов восстановления
+const (
+	defaultLoginChallengeTTL = 5 * time.Minute
+	maxChallengeAttempts     = 5
+	recoveryCodeCount        = 10
+)
+
+var recoveryEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// TwoFactorStatusResponse - состояние двухфакторной аутентификации пользователя
+type TwoFactorStatusResponse struct {
+	Enabled           bool  `json:"enabled"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+// TwoFactorSetupResponse - секрет для приложения-аутентификатора
+type TwoFactorSetupResponse struct {
+	Secret string `json:"secret"`      // base32, для ввода вручную
+	URI    string `json:"otpauth_uri"` // для QR-кода
+}
+
+// TwoFactorCodeRequest - код из приложения-аутентификатора или код восстановления
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse - новые коды восстановления; показываются только один раз
+type RecoveryCodesResponse struct {
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// LoginChallengeResponse - первый шаг входа пройден, нужен код второго фактора (POST /login/2fa)
+type LoginChallengeResponse struct {
+	Message           string `json:"message"`
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int64  `json:"expires_in"` // срок действия challenge_token в секундах
+}
+
+// TwoFactorLoginRequest - второй шаг входа
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// LoginTwoFactorAPI – второй шаг входа
+// @Summary Второй шаг входа
+// @Description Принимает токен первого шага (POST /login) и код из приложения-аутентификатора или одноразовый код восстановления,
+// @Description возвращает пару токенов. Токен первого шага действует TWO_FACTOR_CHALLENGE_TTL и допускает 5 попыток;
+// @Description неверные коды учитываются в блокировке входа после неудачных попыток.
+// @Tags Пользователь
+// @Accept json
+// @Produce json
+// @Param body body controllers.TwoFactorLoginRequest true "Токен первого шага и код"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 401 {object} map[string]string "Invalid or expired challenge or invalid code"
+// @Failure 403 {object} map[string]string "Account disabled"
+// @Failure 429 {object} map[string]interface{} "{"error":string,"retry_after":int}"
+// @Router /login/2fa [post]
+func LoginTwoFactorAPI(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	var challenge models.LoginChallenge
+	if err := db.DB.Where("token_hash = ? AND expires_at > ?", hashToken(req.ChallengeToken), time.Now()).
+		First(&challenge).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge, please log in again"})
+		return
+	}
+	// Попытка учитывается условно, чтобы параллельные запросы не превысили лимит
+	res := db.DB.Model(&models.LoginChallenge{}).
+		Where("token_hash = ? AND attempts < ?", challenge.TokenHash, maxChallengeAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		db.DB.Delete(&challenge)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Too many attempts, please log in again"})
+		return
+	}
+
+	var user models.User
+	if err := db.DB.First(&user, challenge.UserID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge, please log in again"})
+		return
+	}
+	if user.Disabled {
+		db.DB.Delete(&challenge)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		return
+	}
+	if !verifySecondFactor(c, user, req.Code, loginLockoutKey(user.Username), http.StatusUnauthorized) {
+		return
+	}
+
+	db.DB.Delete(&challenge)
+	tokens, err := startSession(c, db.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token generation failed"})
+		return
+	}
+	tokens.Message = "Logged successfully"
+	c.JSON(http.StatusOK, tokens)
+}
+
+// TwoFactorStatusAPI – состояние двухфакторной аутентификации
+// @Summary Состояние двухфакторной аутентификации
+// @Description Включена ли двухфакторная аутентификация и сколько осталось неиспользованных кодов восстановления.
+// @Tags Пользователь
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} TwoFactorStatusResponse
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/user/2fa [get]
+func TwoFactorStatusAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var status TwoFactorStatusResponse
+	enabled, err := twoFactorEnabled(db.DB, userID)
+	if err == nil && enabled {
+		status.Enabled = true
+		err = db.DB.Model(&models.RecoveryCode{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			Count(&status.RecoveryCodesLeft).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// EnrollTwoFactorAPI – подключение двухфакторной аутентификации
+// @Summary Подключение двухфакторной аутентификации
+// @Description Создаёт секрет TOTP (RFC 6238: SHA-1, 6 цифр, 30 секунд) и возвращает его вместе с адресом otpauth:// для QR-кода.
+// @Description Вход начинает требовать код только после подтверждения (POST /api/user/2fa/confirm). Повторный вызов до подтверждения заменяет секрет.
+// @Tags Пользователь
+// @Security BearerAuth
+// @Produce json
+// @Success 201 {object} TwoFactorSetupResponse
+// @Failure 409 {object} map[string]string "Two-factor authentication is already enabled"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/user/2fa [post]
+func EnrollTwoFactorAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	enabled, err := twoFactorEnabled(db.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	var user models.User
+	if err := db.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+	factor := models.TwoFactor{UserID: userID, Secret: secret, CreatedAt: time.Now()}
+	if err := db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "last_counter", "confirmed_at", "created_at"}),
+	}).Create(&factor).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, TwoFactorSetupResponse{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer(), user.Username, secret),
+	})
+}
+
+// ConfirmTwoFactorAPI – подтверждение двухфакторной аутентификации
+// @Summary Подтверждение двухфакторной аутентификации
+// @Description Проверяет код из приложения-аутентификатора и включает двухфакторную аутентификацию.
+// @Description В ответе - одноразовые коды восстановления, они показываются только один раз.
+// @Tags Пользователь
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body TwoFactorCodeRequest true "Код из приложения"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} map[string]string "Invalid code"
+// @Failure 404 {object} map[string]string "Two-factor setup not started"
+// @Failure 409 {object} map[string]string "Two-factor authentication is already enabled"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/user/2fa/confirm [post]
+func ConfirmTwoFactorAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	var factor models.TwoFactor
+	if err := db.DB.Where("user_id = ?", userID).First(&factor).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Two-factor setup not started"})
+		return
+	}
+	if factor.ConfirmedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	counter, ok := totp.Validate(factor.Secret, normalizeCode(req.Code), time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	var codes []string
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.TwoFactor{}).
+			Where("user_id = ? AND confirmed_at IS NULL AND secret = ?", userID, factor.Secret).
+			Updates(map[string]interface{}{"confirmed_at": time.Now(), "last_counter": counter})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		var err error
+		codes, err = issueRecoveryCodes(tx, userID)
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor setup changed, try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{Message: "Two-factor authentication enabled", RecoveryCodes: codes})
+}
+
+// DisableTwoFactorAPI – отключение двухфакторной аутентификации
+// @Summary Отключение двухфакторной аутентификации
+// @Description Отключает двухфакторную аутентификацию после проверки текущего кода или кода восстановления. Коды восстановления удаляются.
+// @Description Неверные коды блокируют проверку кода в настройках учётной записи так же, как неудачный вход, но вход не блокируют.
+// @Tags Пользователь
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body TwoFactorCodeRequest true "Код из приложения или код восстановления"
+// @Success 200 {object} map[string]string "{"message":"Two-factor authentication disabled"}"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Invalid two-factor code"
+// @Failure 404 {object} map[string]string "Two-factor authentication is not enabled"
+// @Failure 429 {object} map[string]interface{} "{"error":string,"retry_after":int}"
+// @Router /api/user/2fa [delete]
+func DisableTwoFactorAPI(c *gin.Context) {
+	user, req, ok := twoFactorAccountRequest(c)
+	if !ok || !verifySecondFactor(c, user, req.Code, accountLockoutKey(user.ID), http.StatusForbidden) {
+		return
+	}
+
+	if err := resetTwoFactor(db.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodesAPI – новые коды восстановления
+// @Summary Новые коды восстановления
+// @Description Заменяет все коды восстановления новыми после проверки текущего кода или кода восстановления.
+// @Tags Пользователь
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body TwoFactorCodeRequest true "Код из приложения или код восстановления"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Invalid two-factor code"
+// @Failure 404 {object} map[string]string "Two-factor authentication is not enabled"
+// @Failure 429 {object} map[string]interface{} "{"error":string,"retry_after":int}"
+// @Router /api/user/2fa/recovery-codes [post]
+func RegenerateRecoveryCodesAPI(c *gin.Context) {
+	user, req, ok := twoFactorAccountRequest(c)
+	if !ok || !verifySecondFactor(c, user, req.Code, accountLockoutKey(user.ID), http.StatusForbidden) {
+		return
+	}
+
+	var codes []string
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = issueRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, RecoveryCodesResponse{Message: "Recovery codes regenerated", RecoveryCodes: codes})
+}
+
+// twoFactorAccountRequest - текущий пользователь с включённой двухфакторной аутентификацией и код из тела запроса
+func twoFactorAccountRequest(c *gin.Context) (models.User, TwoFactorCodeRequest, bool) {
+	userID := c.MustGet("userID").(uint)
+
+	var req TwoFactorCodeRequest
+	var user models.User
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return user, req, false
+	}
+	enabled, err := twoFactorEnabled(db.DB, userID)
+	if err == nil {
+		err = db.DB.First(&user, userID).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return user, req, false
+	}
+	if !enabled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Two-factor authentication is not enabled"})
+		return user, req, false
+	}
+	return user, req, true
+}
+
+// loginLockoutKey - ключ блокировки входа: имя пользователя без учёта регистра
+func loginLockoutKey(username string) string {
+	return strings.ToLower(username)
+}
+
+// accountLockoutKey - ключ блокировки проверок кода в настройках учётной записи. Отдельный от входа,
+// чтобы владелец украденного токена доступа не мог неверными кодами заблокировать вход пользователя.
+func accountLockoutKey(userID uint) string {
+	return fmt.Sprintf("2fa:user:%d", userID)
+}
+
+// verifySecondFactor - проверка кода второго фактора с учётом блокировки по key после неудачных попыток.
+// Успешная проверка сбрасывает счётчик неудач key.
+// При отказе ответ уже отправлен: 429 при блокировке, failStatus при неверном коде.
+func verifySecondFactor(c *gin.Context, user models.User, code, key string, failStatus int) bool {
+	ctx := c.Request.Context()
+	lockout := ratelimit.LockoutFromEnv()
+	if left, err := lockout.Check(ctx, key); err != nil {
+		log.Printf("Lockout check failed: %v", err)
+	} else if left > 0 {
+		middleware.AbortTooManyRequests(c, left, "Too many failed two-factor attempts, try again later")
+		return false
+	}
+
+	ok, err := checkSecondFactor(db.DB, user.ID, code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+	if !ok {
+		if _, err := lockout.Fail(ctx, key); err != nil {
+			log.Printf("Lockout update failed: %v", err)
+		}
+		c.JSON(failStatus, gin.H{"error": "Invalid two-factor code"})
+		return false
+	}
+	if err := lockout.Success(ctx, key); err != nil {
+		log.Printf("Lockout reset failed: %v", err)
+	}
+	return true
+}
+
+// checkSecondFactor - проверка кода TOTP или кода восстановления. Принятый код больше не принимается:
+// для TOTP запоминается период кода, код восстановления помечается использованным.
+func checkSecondFactor(tx *gorm.DB, userID uint, code string) (bool, error) {
+	var factor models.TwoFactor
+	if err := tx.Where("user_id = ? AND confirmed_at IS NOT NULL", userID).First(&factor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	code = normalizeCode(code)
+	if counter, ok := totp.Validate(factor.Secret, code, time.Now()); ok {
+		// Условное обновление: из двух параллельных запросов с одним кодом пройдёт только один
+		res := tx.Model(&models.TwoFactor{}).
+			Where("user_id = ? AND last_counter < ?", userID, counter).
+			Update("last_counter", counter)
+		return res.RowsAffected == 1, res.Error
+	}
+	res := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashToken(code)).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+// twoFactorEnabled - включена ли у пользователя двухфакторная аутентификация (настройка подтверждена)
+func twoFactorEnabled(tx *gorm.DB, userID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.TwoFactor{}).Where("user_id = ? AND confirmed_at IS NOT NULL", userID).Count(&count).Error
+	return count > 0, err
+}
+
+// issueRecoveryCodes - замена кодов восстановления пользователя новыми
+func issueRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code := randomToken(5, recoveryEncoding.EncodeToString)
+		codes[i] = code[:4] + "-" + code[4:]
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: hashToken(code)}
+	}
+	return codes, tx.Create(&records).Error
+}
+
+// resetTwoFactor - отключение двухфакторной аутентификации и незавершённых входов пользователя
+func resetTwoFactor(tx *gorm.DB, userID uint) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TwoFactor{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.LoginChallenge{}).Error
+	})
+}
+
+// startLoginChallenge - токен первого шага входа для пользователя с двухфакторной аутентификацией
+func startLoginChallenge(userID uint) (LoginChallengeResponse, error) {
+	ttl := durationFromEnv("TWO_FACTOR_CHALLENGE_TTL", defaultLoginChallengeTTL)
+	token := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	err := db.DB.Create(&models.LoginChallenge{
+		TokenHash: hashToken(token),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(ttl),
+	}).Error
+	return LoginChallengeResponse{
+		Message:           "Two-factor code required",
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         int64(ttl.Seconds()),
+	}, err
+}
+
+// normalizeCode - код без пробелов и дефисов в нижнем регистре: "1234 56" и "ABCD-EFGH" вводятся как удобно
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
+
+// totpIssuer - название сервиса в приложении-аутентификаторе из TOTP_ISSUER (по умолчанию LestaStartTest)
+func totpIssuer() string {
+	if v := os.Getenv("TOTP_ISSUER"); v != "" {
+		return v
+	}
+	return "LestaStartTest"
+}
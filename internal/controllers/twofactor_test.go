@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/models"
+	"LestaStartTest/internal/ratelimit"
+	"LestaStartTest/internal/totp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testPassword = "Str0ng-pass!"
+
+// setupTwoFactor - SQLite во временном каталоге, пользователь dave с включённой двухфакторной аутентификацией
+// и маршруты входа и настроек учётной записи (userID подставляется вместо проверки токена)
+func setupTwoFactor(t *testing.T) (*gin.Engine, models.User) {
+	t.Helper()
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	previousDB := db.DB
+	db.DB = conn
+	t.Cleanup(func() {
+		db.DB = previousDB
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	require.NoError(t, db.DB.AutoMigrate(&models.User{}, &models.TwoFactor{}, &models.RecoveryCode{}, &models.LoginChallenge{}))
+
+	previous := ratelimit.Default
+	ratelimit.Default = ratelimit.NewMemoryStore()
+	t.Cleanup(func() { ratelimit.Default = previous })
+	t.Setenv("LOGIN_LOCKOUT_THRESHOLD", "5")
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := models.User{Username: "dave", Password: string(hash), Role: models.RoleUser}
+	require.NoError(t, db.DB.Create(&user).Error)
+	secret, err := totp.NewSecret()
+	require.NoError(t, err)
+	confirmed := time.Now()
+	require.NoError(t, db.DB.Create(&models.TwoFactor{UserID: user.ID, Secret: secret, ConfirmedAt: &confirmed, CreatedAt: confirmed}).Error)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/login", LoginAPI)
+	r.POST("/login/2fa", LoginTwoFactorAPI)
+	account := r.Group("/api/user", func(c *gin.Context) { c.Set("userID", user.ID) })
+	account.DELETE("/2fa", DisableTwoFactorAPI)
+	return r, user
+}
+
+func doJSON(r *gin.Engine, method, url string, body interface{}) (int, map[string]interface{}) {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, url, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var out map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	return w.Code, out
+}
+
+func login(t *testing.T, r *gin.Engine) (int, string) {
+	t.Helper()
+	code, out := doJSON(r, http.MethodPost, "/login", AuthRequest{Username: "dave", Password: testPassword})
+	token, _ := out["challenge_token"].(string)
+	return code, token
+}
+
+// Повторный ввод пароля не сбрасывает неверные попытки кода второго фактора
+func TestLoginDoesNotResetSecondFactorFailures(t *testing.T) {
+	r, _ := setupTwoFactor(t)
+
+	code, challenge := login(t, r)
+	require.Equal(t, http.StatusAccepted, code)
+	for i := 0; i < 4; i++ {
+		code, _ = doJSON(r, http.MethodPost, "/login/2fa", TwoFactorLoginRequest{ChallengeToken: challenge, Code: "000000"})
+		require.Equal(t, http.StatusUnauthorized, code)
+	}
+
+	code, challenge = login(t, r)
+	require.Equal(t, http.StatusAccepted, code)
+	code, _ = doJSON(r, http.MethodPost, "/login/2fa", TwoFactorLoginRequest{ChallengeToken: challenge, Code: "000000"})
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = login(t, r)
+	assert.Equal(t, http.StatusTooManyRequests, code, "пятая неудача подряд блокирует вход")
+}
+
+// Неверные коды в настройках учётной записи не блокируют вход
+func TestAccountCodeFailuresDoNotLockLogin(t *testing.T) {
+	r, _ := setupTwoFactor(t)
+
+	for i := 0; i < 5; i++ {
+		code, _ := doJSON(r, http.MethodDelete, "/api/user/2fa", TwoFactorCodeRequest{Code: "000000"})
+		require.Equal(t, http.StatusForbidden, code)
+	}
+	code, _ := doJSON(r, http.MethodDelete, "/api/user/2fa", TwoFactorCodeRequest{Code: "000000"})
+	assert.Equal(t, http.StatusTooManyRequests, code)
+
+	code, _ = login(t, r)
+	assert.Equal(t, http.StatusAccepted, code)
+}
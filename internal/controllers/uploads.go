@@ -0,0 +1,439 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"LestaStartTest/internal/charset"
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/jobs"
+	"LestaStartTest/internal/models"
+	"LestaStartTest/internal/quota"
+	"LestaStartTest/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Версия протокола tus, на которой основаны возобновляемые загрузки
+const tusVersion = "1.0.0"
+
+// Срок жизни незавершённой сессии и период удаления истёкших сессий
+const (
+	defaultUploadSessionTTL    = 24 * time.Hour
+	uploadSessionPurgeInterval = 10 * time.Minute
+)
+
+// CreateUploadAPI – создание сессии возобновляемой загрузки
+// @Summary Начало возобновляемой загрузки
+// @Description Создаёт сессию загрузки одного файла по частям (протокол в стиле tus). Размер файла передаётся в Upload-Length,
+// @Description параметры - в Upload-Metadata: пары "ключ base64(значение)" через запятую. Обязателен ключ filename,
+// @Description необязательны encoding, on_conflict, archive_collection и atomic (как у POST /api/documents/upload).
+// @Description Незавершённая сессия удаляется через UPLOAD_SESSION_TTL (по умолчанию 24h) после получения последней части.
+// @Tags Документы
+// @Security BearerAuth
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param Upload-Length header int true "Размер файла в байтах"
+// @Param Upload-Metadata header string true "Например: filename cmVwb3J0LnR4dA==,on_conflict cmVwbGFjZQ=="
+// @Success 201 {object} map[string]interface{} "{"id":string,"offset":0,"length":int,"expires_at":string}, заголовок Location - адрес сессии"
+// @Failure 400 {object} map[string]string "Invalid Upload-Length or Upload-Metadata, unknown encoding or on_conflict"
+// @Failure 413 {object} map[string]string "Превышен лимит размера файла или квота пользователя"
+// @Failure 500 {object} map[string]string "Failed to create upload session"
+// @Router /api/uploads [post]
+func CreateUploadAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	c.Header("Tus-Resumable", tusVersion)
+
+	size, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || size < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Length must be a positive number"})
+		return
+	}
+	meta, err := parseUploadMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil || meta["filename"] == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Metadata must contain base64 encoded filename"})
+		return
+	}
+	encoding, onConflict, err := normalizeUploadOptions(meta["encoding"], meta["on_conflict"])
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Размер файла резервируется в квоте до завершения сессии
+	limits := quota.LimitsFromEnv()
+	if err := limits.CheckFile(meta["filename"], size); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	usage, err := reservedUsage(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error checking quota"})
+		return
+	}
+	if err := limits.CheckUsage(usage, quota.Usage{Bytes: size}); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	session := models.UploadSession{
+		ID:                hex.EncodeToString(id),
+		UserID:            userID,
+		WorkspaceID:       requestScope(c).WorkspaceID,
+		Filename:          meta["filename"],
+		Size:              size,
+		Encoding:          encoding,
+		OnConflict:        onConflict,
+		ArchiveCollection: meta["archive_collection"] == "true",
+		Atomic:            meta["atomic"] == "true",
+		ExpiresAt:         time.Now().Add(uploadSessionTTL()),
+	}
+	if err := db.DB.Create(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload session"})
+		return
+	}
+
+	c.Header("Location", "/api/uploads/"+session.ID)
+	setUploadHeaders(c, session)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":         session.ID,
+		"offset":     session.Received,
+		"length":     session.Size,
+		"expires_at": session.ExpiresAt,
+	})
+}
+
+// UploadOffsetAPI – текущее смещение сессии
+// @Summary Смещение возобновляемой загрузки
+// @Description Возвращает в заголовке Upload-Offset, сколько байт уже получено. С этого смещения клиент продолжает загрузку после обрыва.
+// @Tags Документы
+// @Security BearerAuth
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path string true "ID сессии"
+// @Success 200 "Заголовки Upload-Offset, Upload-Length и Upload-Expires"
+// @Failure 404 "Upload session not found or expired"
+// @Router /api/uploads/{id} [head]
+func UploadOffsetAPI(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	session, ok := findUploadSession(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	setUploadHeaders(c, session)
+	c.Status(http.StatusOK)
+}
+
+// UploadChunkAPI – загрузка части файла
+// @Summary Часть возобновляемой загрузки
+// @Description Дописывает тело запроса к файлу с указанного смещения. Upload-Offset должен совпадать с текущим смещением сессии,
+// @Description тело передаётся с Content-Type application/offset+octet-stream и обязательным Content-Length.
+// @Description Если часть получена не полностью, она не сохраняется и смещение не меняется.
+// @Tags Документы
+// @Security BearerAuth
+// @Accept application/offset+octet-stream
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path string true "ID сессии"
+// @Param Upload-Offset header int true "Смещение, с которого начинается часть"
+// @Success 204 "Часть сохранена, новое смещение в заголовке Upload-Offset"
+// @Failure 400 {object} map[string]string "Invalid Upload-Offset"
+// @Failure 404 {object} map[string]string "Upload session not found or expired"
+// @Failure 409 {object} map[string]string "Upload-Offset does not match, текущее смещение в заголовке Upload-Offset"
+// @Failure 411 {object} map[string]string "Content-Length required"
+// @Failure 413 {object} map[string]string "Chunk exceeds Upload-Length"
+// @Failure 415 {object} map[string]string "Content-Type must be application/offset+octet-stream"
+// @Failure 500 {object} map[string]string "Failed to store chunk"
+// @Router /api/uploads/{id} [patch]
+func UploadChunkAPI(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	ctx := c.Request.Context()
+
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/offset+octet-stream"})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Offset"})
+		return
+	}
+	length := c.Request.ContentLength
+	if length < 0 {
+		c.JSON(http.StatusLengthRequired, gin.H{"error": "Content-Length required"})
+		return
+	}
+
+	session, ok := findUploadSession(c)
+	if !ok {
+		return
+	}
+	if offset != session.Received {
+		setUploadHeaders(c, session)
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset does not match"})
+		return
+	}
+	if offset+length > session.Size {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Chunk exceeds Upload-Length"})
+		return
+	}
+	if length == 0 {
+		setUploadHeaders(c, session)
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	// Каждая часть - отдельный объект, поэтому дописывание работает и в S3.
+	// Случайный суффикс отделяет попытки с одним смещением (повтор после таймаута, параллельный запрос):
+	// проигравший запрос удаляет только свой объект, а не часть, сохранённую победившим.
+	key := fmt.Sprintf("sessions/%s/%020d-%s", session.ID, offset, randomToken(8, hex.EncodeToString))
+	body := &countingReader{r: io.LimitReader(c.Request.Body, length)}
+	err = storage.Blobs.Put(ctx, key, body, length)
+	if err == nil && body.n != length {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		// Оборванная часть отбрасывается целиком, клиент повторит её с прежнего смещения
+		storage.Blobs.Delete(context.Background(), key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store chunk"})
+		return
+	}
+
+	// Смещение меняется, только если параллельный запрос не успел изменить его раньше
+	session.Received = offset + length
+	session.Chunks = append(session.Chunks, key)
+	session.ExpiresAt = time.Now().Add(uploadSessionTTL())
+	res := db.DB.Model(&session).Where("received = ?", offset).
+		Select("received", "chunks", "expires_at").
+		Updates(&session)
+	if res.Error != nil || res.RowsAffected == 0 {
+		storage.Blobs.Delete(context.Background(), key)
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store chunk"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset changed by a concurrent request"})
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Status(http.StatusNoContent)
+}
+
+// FinalizeUploadAPI – завершение возобновляемой загрузки
+// @Summary Завершение возобновляемой загрузки
+// @Description Передаёт полностью полученный файл в обычную обработку загрузок. Ответ такой же, как у POST /api/documents/upload:
+// @Description фоновая задача, статус которой доступен в GET /api/jobs/{id}. Сессия после этого удаляется.
+// @Tags Документы
+// @Security BearerAuth
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path string true "ID сессии"
+// @Success 202 {object} JobResponse "Задача создана, заголовок Location указывает на её статус"
+// @Failure 404 {object} map[string]string "Upload session not found or expired"
+// @Failure 409 {object} map[string]string "Upload is incomplete"
+// @Failure 500 {object} map[string]string "Failed to create job"
+// @Router /api/uploads/{id}/finalize [post]
+func FinalizeUploadAPI(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	session, ok := findUploadSession(c)
+	if !ok {
+		return
+	}
+	if session.Received != session.Size {
+		setUploadHeaders(c, session)
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Upload is incomplete: %d of %d bytes received", session.Received, session.Size)})
+		return
+	}
+
+	// Удаление сессии защищает от повторного завершения; части переходят в задачу
+	res := db.DB.Delete(&session)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload session not found or expired"})
+		return
+	}
+
+	params := uploadJob{
+		Files:             []uploadFile{{Name: session.Filename, Parts: session.Chunks}},
+		Encoding:          session.Encoding,
+		OnConflict:        session.OnConflict,
+		ArchiveCollection: session.ArchiveCollection,
+		Atomic:            session.Atomic,
+		WorkspaceID:       session.WorkspaceID,
+	}
+	job := models.Job{UserID: session.UserID, Type: jobUpload, Total: 1, Files: session.Chunks}
+	if err := jobs.Create(&job, params); err != nil {
+		db.RemoveBlobFiles(c.Request.Context(), session.Chunks)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job"})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/jobs/%d", job.ID))
+	c.JSON(http.StatusAccepted, jobResponse(job))
+}
+
+// DeleteUploadAPI – отмена возобновляемой загрузки
+// @Summary Отмена возобновляемой загрузки
+// @Description Удаляет сессию и все полученные части.
+// @Tags Документы
+// @Security BearerAuth
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path string true "ID сессии"
+// @Success 204 "Сессия удалена"
+// @Failure 404 {object} map[string]string "Upload session not found or expired"
+// @Router /api/uploads/{id} [delete]
+func DeleteUploadAPI(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	session, ok := findUploadSession(c)
+	if !ok {
+		return
+	}
+	if res := db.DB.Delete(&session); res.Error != nil || res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload session not found or expired"})
+		return
+	}
+	db.RemoveBlobFiles(c.Request.Context(), session.Chunks)
+	c.Status(http.StatusNoContent)
+}
+
+// StartUploadSessionPurge - фоновое удаление истёкших сессий загрузки вместе с полученными частями
+func StartUploadSessionPurge(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(uploadSessionPurgeInterval)
+		defer ticker.Stop()
+		for {
+			if err := PurgeUploadSessions(ctx, time.Now()); err != nil {
+				log.Printf("Upload session purge failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// PurgeUploadSessions - удаление сессий, истёкших к моменту now
+func PurgeUploadSessions(ctx context.Context, now time.Time) error {
+	var expired []models.UploadSession
+	if err := db.DB.Where("expires_at < ?", now).Find(&expired).Error; err != nil {
+		return err
+	}
+	for _, session := range expired {
+		// Сессию могли продлить, пока шёл поиск
+		res := db.DB.Where("id = ? AND expires_at < ?", session.ID, now).Delete(&models.UploadSession{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 1 {
+			db.RemoveBlobFiles(ctx, session.Chunks)
+		}
+	}
+	if len(expired) > 0 {
+		log.Printf("Upload sessions purged: %d", len(expired))
+	}
+	return nil
+}
+
+// findUploadSession - действующая сессия пользователя из пути запроса; при отсутствии отвечает 404
+func findUploadSession(c *gin.Context) (models.UploadSession, bool) {
+	userID := c.MustGet("userID").(uint)
+	var session models.UploadSession
+	if err := db.DB.Where("id = ? AND user_id = ? AND expires_at > ?", c.Param("id"), userID, time.Now()).
+		First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload session not found or expired"})
+		return session, false
+	}
+	return session, true
+}
+
+func setUploadHeaders(c *gin.Context, session models.UploadSession) {
+	c.Header("Upload-Offset", strconv.FormatInt(session.Received, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.Size, 10))
+	c.Header("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// parseUploadMetadata - разбор заголовка Upload-Metadata: "ключ base64,ключ base64"
+func parseUploadMetadata(header string) (map[string]string, error) {
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, " ")
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("metadata %s: %w", key, err)
+		}
+		meta[key] = string(decoded)
+	}
+	return meta, nil
+}
+
+// uploadSessionTTL - срок жизни незавершённой сессии из UPLOAD_SESSION_TTL (по умолчанию 24 часа)
+func uploadSessionTTL() time.Duration {
+	return durationFromEnv("UPLOAD_SESSION_TTL", defaultUploadSessionTTL)
+}
+
+// reservedUsage - потребление пользователя вместе с размером незавершённых сессий загрузки
+func reservedUsage(userID uint) (quota.Usage, error) {
+	usage, err := userUsage(userID)
+	if err != nil {
+		return usage, err
+	}
+	var pending int64
+	err = db.DB.Model(&models.UploadSession{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&pending).Error
+	usage.Bytes += pending
+	return usage, err
+}
+
+// countingReader - подсчёт прочитанных байт, чтобы отличить полную часть от оборванной
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// normalizeUploadOptions - проверка кодировки и политики конфликтов имён, общая для всех способов загрузки
+func normalizeUploadOptions(encoding, onConflict string) (string, string, error) {
+	if encoding != "" {
+		var err error
+		if encoding, err = charset.Normalize(encoding); err != nil {
+			return "", "", errors.New("Unknown encoding")
+		}
+	}
+	switch onConflict {
+	case "":
+		onConflict = conflictRename
+	case conflictRename, conflictReplace, conflictReject:
+	default:
+		return "", "", errors.New("on_conflict must be rename, replace or reject")
+	}
+	return encoding, onConflict, nil
+}
@@ -1,15 +1,18 @@
 package controllers
 
 import (
+	"context"
 	"net/http"
-	"os"
 	"strconv"
 
+	"LestaStartTest/internal/credentials"
 	"LestaStartTest/internal/db"
 	"LestaStartTest/internal/models"
+	"LestaStartTest/internal/quota"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // ChangePasswordRequest - необходима, чтобы swag не ругался на анонимную структуру, и документация сгенерировалась корректно
@@ -19,14 +22,15 @@ type ChangePasswordRequest struct {
 
 // ChangePasswordAPI – изменение пароля
 // @Summary Изменение пароля пользователя
-// @Description Обновляет пароль текущего пользователя.
+// @Description Обновляет пароль текущего пользователя и отзывает все его токены на всех устройствах.
+// @Description В ответе - новая пара токенов для текущего клиента. Пароль проверяется по тем же правилам, что при регистрации.
 // @Tags Пользователь
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param user_id path int true "ID пользователя"
 // @Param body body ChangePasswordRequest true "Новый пароль"
-// @Success 200 {object} map[string]string "{"message":"Password updated"}"
+// @Success 200 {object} TokenResponse
 // @Failure 400 {object} map[string]string "Invalid input"
 // @Failure 403 {object} map[string]string "Forbidden"
 // @Failure 500 {object} map[string]string "Password update failed or database error"
@@ -51,6 +55,15 @@ func ChangePasswordAPI(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
+	var user models.User
+	if err := db.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if err := credentials.PolicyFromEnv().CheckPassword(input.NewPassword, user.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
@@ -58,23 +71,64 @@ func ChangePasswordAPI(c *gin.Context) {
 		return
 	}
 
-	if err := db.DB.Model(&models.User{}).Where("id = ?", userID).Update("password", string(hashedPassword)).Error; err != nil {
+	var tokens TokenResponse
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		if err := revokeAllTokens(tx, userID); err != nil {
+			return err
+		}
+		tokens, err = startSession(c, tx, userID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	tokens.Message = "Password updated"
+	c.JSON(http.StatusOK, tokens)
+}
+
+// UsageResponse - потребление пользователя и действующие ограничения
+type UsageResponse struct {
+	Usage  quota.Usage  `json:"usage"`
+	Limits quota.Limits `json:"limits"`
+}
+
+// UsageAPI – потребление хранилища
+// @Summary Использование квоты
+// @Description Возвращает суммарный размер всех версий документов пользователя и количество документов вместе с ограничениями.
+// @Description Документы в корзине учитываются до окончательного удаления. Нулевой лимит означает отсутствие ограничения.
+// @Tags Пользователь
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} UsageResponse
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/user/usage [get]
+func UsageAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	usage, err := userUsage(userID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Password updated"})
+	c.JSON(http.StatusOK, UsageResponse{Usage: usage, Limits: quota.LimitsFromEnv()})
 }
 
 // DeleteUserAPI – удаление пользователя
 // @Summary Удаление пользователя
-// @Description Удаляет пользователя, его документы и коллекции.
+// @Description Удаляет пользователя, его документы и коллекции. Все токены пользователя перестают действовать.
 // @Tags Пользователь
 // @Security BearerAuth
 // @Produce json
 // @Param user_id path int true "ID пользователя"
 // @Success 200 {object} map[string]string "{"message":"User deleted"}"
 // @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 500 {object} map[string]string "Failed to delete user"
 // @Router /user/{user_id} [delete]
 func DeleteUserAPI(c *gin.Context) {
 	userID := c.MustGet("userID").(uint)
@@ -89,21 +143,82 @@ func DeleteUserAPI(c *gin.Context) {
 		return
 	}
 
-	// Удаляем файлы пользователя
-	var documents []models.Document
-	db.DB.Where("user_id = ?", userID).Find(&documents)
-	for _, doc := range documents {
-		os.Remove(doc.OriginalPath) // Удаляем физический файл
+	if err := deleteUser(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
 	}
 
-	db.DB.Where("collection_id IN (SELECT id FROM collections WHERE user_id = ?)", userID).Delete(&models.CollectionIDF{})
+	c.SetCookie("auth", "", -1, "/", "", false, true)
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// deleteUser - окончательное удаление пользователя со всеми личными документами, коллекциями, задачами и учётными данными.
+// Документы и коллекции рабочих пространств остаются у пространств.
+func deleteUser(ctx context.Context, userID uint) error {
+	if err := leaveWorkspaces(ctx, userID); err != nil {
+		return err
+	}
 
+	var documentIDs, collectionIDs []uint
+	db.DB.Unscoped().Model(&models.Document{}).Where("user_id = ? AND workspace_id IS NULL", userID).Pluck("id", &documentIDs)
+	db.DB.Unscoped().Model(&models.Collection{}).Where("user_id = ? AND workspace_id IS NULL", userID).Pluck("id", &collectionIDs)
+	// Чужие коллекции, в которые пользователь добавлял документы, после удаления нужно пересчитать
+	var sharedIDs []uint
+	db.DB.Table("collection_documents").
+		Distinct("collection_id").
+		Joins("JOIN collections ON collections.id = collection_documents.collection_id").
+		Where("collection_documents.document_id IN ? AND (collections.user_id <> ? OR collections.workspace_id IS NOT NULL)", documentIDs, userID).
+		Pluck("collection_id", &sharedIDs)
+	var userJobs []models.Job
+	db.DB.Where("user_id = ?", userID).Find(&userJobs)
+	var sessions []models.UploadSession
+	db.DB.Where("user_id = ?", userID).Find(&sessions)
+
+	// Удаление окончательное, включая корзину; файлы удаляются, если на их содержимое больше никто не ссылается
 	tx := db.DB.Begin()
-	tx.Where("user_id = ?", userID).Delete(&models.Document{})
-	tx.Where("user_id = ?", userID).Delete(&models.Collection{})
+	staleKeys, err := purgeDocuments(tx, documentIDs)
+	if err == nil {
+		err = purgeCollections(tx, collectionIDs)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	tx.Where("user_id = ?", userID).Delete(&models.Job{})
+	tx.Where("user_id = ?", userID).Delete(&models.UploadSession{})
+	tx.Where("user_id = ?", userID).Delete(&models.Session{})
+	tx.Where("user_id = ?", userID).Delete(&models.APIKey{})
+	tx.Where("user_id = ?", userID).Delete(&models.CollectionShare{})
+	tx.Where("user_id = ?", userID).Delete(&models.RefreshToken{})
+	tx.Where("user_id = ?", userID).Delete(&models.TwoFactor{})
+	tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{})
+	tx.Where("user_id = ?", userID).Delete(&models.LoginChallenge{})
 	tx.Delete(&models.User{}, userID)
-	tx.Commit()
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	for _, job := range userJobs {
+		staleKeys = append(staleKeys, job.Files...)
+	}
+	for _, session := range sessions {
+		staleKeys = append(staleKeys, session.Chunks...)
+	}
+	db.RemoveBlobFiles(ctx, staleKeys)
+	return recalcCollections(ctx, sharedIDs)
+}
 
-	c.SetCookie("auth", "", -1, "/", "", false, true)
-	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+// userUsage - текущее потребление пользователя. Размер считается по всем версиям, включая документы в корзине:
+// пользователь платит за каждую загрузку, даже если такое же содержимое уже хранится у кого-то ещё.
+func userUsage(userID uint) (quota.Usage, error) {
+	var usage quota.Usage
+	if err := db.DB.Unscoped().Model(&models.Document{}).
+		Where("user_id = ?", userID).
+		Count(&usage.Documents).Error; err != nil {
+		return usage, err
+	}
+	err := db.DB.Raw(`SELECT COALESCE(SUM(blobs.size), 0) FROM document_versions
+		JOIN documents ON documents.id = document_versions.document_id
+		JOIN blobs ON blobs.hash = document_versions.blob_hash
+		WHERE documents.user_id = ?`, userID).Scan(&usage.Bytes).Error
+	return usage, err
 }
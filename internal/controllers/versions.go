@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LestaStartTest/internal/calculation"
+	"LestaStartTest/internal/charset"
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/extract"
+	"LestaStartTest/internal/models"
+	"LestaStartTest/internal/quota"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VersionResponse - версия документа в ответах API
+type VersionResponse struct {
+	Version   int       `json:"version"`
+	Size      int64     `json:"size"`
+	Encoding  string    `json:"encoding"`
+	MimeType  string    `json:"mime_type"`
+	Current   bool      `json:"current"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UploadVersionAPI – загрузка новой версии документа
+// @Summary Новая версия документа
+// @Description Загружает новое содержимое документа. ID, имя и членство в коллекциях сохраняются, IDF коллекций пересчитывается.
+// @Description Если содержимое совпадает с текущей версией, новая версия не создаётся и возвращается текущая.
+// @Tags Документы
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path int true "ID документа"
+// @Param file formData file true "Новое содержимое документа"
+// @Param encoding formData string false "Кодировка файла (UTF-8, UTF-16LE, UTF-16BE, windows-1251, KOI8-R, IBM866), по умолчанию определяется автоматически"
+// @Success 200 {object} VersionResponse "Содержимое не изменилось"
+// @Success 201 {object} VersionResponse
+// @Failure 400 {object} map[string]string "No file uploaded, unknown encoding, archive or unsupported file type"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 413 {object} map[string]string "Превышен лимит размера файла или квота пользователя"
+// @Failure 500 {object} map[string]string "Failed to save version"
+// @Router /api/documents/{id} [put]
+func UploadVersionAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	ctx := c.Request.Context()
+
+	var document models.Document
+	if err := requestScope(c).where(db.DB, "documents").Where("documents.id = ?", id).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+
+	limits := quota.LimitsFromEnv()
+	if limits.MaxFileSize > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxFileSize+1<<20)
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body exceeds " + quota.FormatBytes(tooLarge.Limit)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	if err := limits.CheckFile(file.Filename, file.Size); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+
+	encoding := c.PostForm("encoding")
+	if encoding != "" {
+		if encoding, err = charset.Normalize(encoding); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown encoding"})
+			return
+		}
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error opening file"})
+		return
+	}
+	defer src.Close()
+	data, err := io.ReadAll(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error reading file"})
+		return
+	}
+
+	if isArchive(extract.DetectMIME(file.Filename, data)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Archive cannot be uploaded as a document version"})
+		return
+	}
+
+	// Тип определяется по имени документа, а не по имени нового файла
+	blob, err := newBlob(document.Filename, data, encoding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if blob.Hash == document.BlobHash {
+		current, err := findVersion(document.ID, document.Version)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load version"})
+			return
+		}
+		c.JSON(http.StatusOK, versionResponse(current, document.Version))
+		return
+	}
+
+	// Новая версия занимает место, но не добавляет документ
+	usage, err := userUsage(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error checking quota"})
+		return
+	}
+	if err := limits.CheckUsage(usage, quota.Usage{Bytes: blob.Size}); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+
+	document.Blob = blob
+	tx := db.DB.Begin()
+	known, err := saveVersion(ctx, tx, &document, data)
+	if err != nil {
+		tx.Rollback()
+		if !known {
+			db.RemoveBlobFiles(ctx, []string{blob.StorageKey})
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save version"})
+		return
+	}
+	tx.Commit()
+
+	if err := recalcDocumentCollections(ctx, []uint{document.ID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update IDF"})
+		return
+	}
+
+	created, err := findVersion(document.ID, document.Version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load version"})
+		return
+	}
+	c.JSON(http.StatusCreated, versionResponse(created, document.Version))
+}
+
+// ListVersionsAPI – история версий документа
+// @Summary Версии документа
+// @Description Возвращает все версии документа, начиная с последней.
+// @Tags Документы
+// @Security BearerAuth
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path int true "ID документа"
+// @Success 200 {object} map[string]interface{} "{"document_id":int,"versions":[]VersionResponse}"
+// @Failure 404 {object} map[string]string "Document not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/documents/{id}/versions [get]
+func ListVersionsAPI(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var document models.Document
+	if err := requestScope(c).where(db.DB, "documents").Where("documents.id = ?", id).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+
+	var versions []models.DocumentVersion
+	if err := db.DB.Preload("Blob").
+		Where("document_id = ?", document.ID).
+		Order("number DESC").
+		Find(&versions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	response := make([]VersionResponse, len(versions))
+	for i, v := range versions {
+		response[i] = versionResponse(v, document.Version)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"document_id": document.ID,
+		"versions":    response,
+	})
+}
+
+// GetVersionAPI – содержимое версии документа
+// @Summary Получение версии документа
+// @Description Возвращает текст, кодировку и MIME-тип указанной версии документа.
+// @Tags Документы
+// @Security BearerAuth
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path int true "ID документа"
+// @Param version path int true "Номер версии"
+// @Success 200 {object} DocumentResponse
+// @Failure 404 {object} map[string]string "Document or version not found"
+// @Router /api/documents/{id}/versions/{version} [get]
+func GetVersionAPI(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	number, _ := strconv.Atoi(c.Param("version"))
+
+	var document models.Document
+	if err := requestScope(c).where(db.DB, "documents").Preload("Tags").Where("documents.id = ?", id).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+
+	version, err := findVersion(document.ID, number)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Version not found"})
+		return
+	}
+
+	response := documentResponse(document)
+	response.Content = version.Blob.Content
+	response.Encoding = version.Blob.Encoding
+	response.MimeType = version.Blob.MimeType
+	response.Size = version.Blob.Size
+	response.Version = version.Number
+	c.JSON(http.StatusOK, response)
+}
+
+// DiffVersionsAPI – сравнение версий документа
+// @Summary Разница TF между версиями
+// @Description Сравнивает TF слов двух версий документа. Слова с неизменным TF не возвращаются,
+// @Description остальные отсортированы по убыванию модуля изменения.
+// @Tags Документы
+// @Security BearerAuth
+// @Produce json
+// @Param X-Workspace-ID header int false "ID рабочего пространства; без заголовка - личное пространство"
+// @Param id path int true "ID документа"
+// @Param from query int false "Исходная версия (по умолчанию предпоследняя)"
+// @Param to query int false "Сравниваемая версия (по умолчанию текущая)"
+// @Success 200 {object} map[string]interface{} "{"document_id":int,"from":int,"to":int,"terms":[]calculation.TermDiff}"
+// @Failure 400 {object} map[string]string "Invalid version number"
+// @Failure 404 {object} map[string]string "Document or version not found"
+// @Router /api/documents/{id}/diff [get]
+func DiffVersionsAPI(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var document models.Document
+	if err := requestScope(c).where(db.DB, "documents").Where("documents.id = ?", id).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+
+	from, to := max(document.Version-1, 1), document.Version
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version number"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version number"})
+			return
+		}
+	}
+
+	fromVersion, err := findVersion(document.ID, from)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Version not found"})
+		return
+	}
+	toVersion, err := findVersion(document.ID, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Version not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"document_id": document.ID,
+		"from":        from,
+		"to":          to,
+		"terms":       calculation.DiffTf(fromVersion.Blob.ProcessedContent, toVersion.Blob.ProcessedContent),
+	})
+}
+
+// saveVersion - сохранение doc.Blob как новой версии документа внутри транзакции tx.
+// Новый документ (doc.ID == 0) создаётся с версией 1, у существующего номер версии увеличивается.
+// known - содержимое уже было в хранилище и файл не записывался.
+func saveVersion(ctx context.Context, tx *gorm.DB, doc *models.Document, data []byte) (known bool, err error) {
+	if known, err = db.AcquireBlob(ctx, tx, doc.Blob, data); err != nil {
+		return known, err
+	}
+	doc.BlobHash = doc.Blob.Hash
+
+	if doc.ID == 0 {
+		doc.Version = 1
+		err = tx.Omit("Blob").Create(doc).Error
+	} else {
+		// Номер версии увеличивается в БД, чтобы параллельные загрузки не получили одинаковый номер
+		err = tx.Model(doc).Clauses(clause.Returning{Columns: []clause.Column{{Name: "version"}}}).
+			Updates(map[string]interface{}{
+				"blob_hash": doc.BlobHash,
+				"version":   gorm.Expr("version + 1"),
+			}).Error
+	}
+	if err != nil {
+		return known, err
+	}
+
+	return known, tx.Create(&models.DocumentVersion{
+		DocumentID: doc.ID,
+		Number:     doc.Version,
+		BlobHash:   doc.BlobHash,
+	}).Error
+}
+
+// releaseDocuments - снятие ссылок на содержимое всех версий документов перед их удалением.
+// Возвращает ключи файлов, на которые больше никто не ссылается.
+func releaseDocuments(tx *gorm.DB, documentIDs []uint) ([]string, error) {
+	var hashes []string
+	if err := tx.Model(&models.DocumentVersion{}).
+		Where("document_id IN ?", documentIDs).
+		Pluck("blob_hash", &hashes).Error; err != nil {
+		return nil, err
+	}
+
+	var staleKeys []string
+	for _, hash := range hashes {
+		key, err := db.ReleaseBlob(tx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if key != "" {
+			staleKeys = append(staleKeys, key)
+		}
+	}
+	return staleKeys, nil
+}
+
+// findVersion - версия документа вместе с содержимым
+func findVersion(documentID uint, number int) (models.DocumentVersion, error) {
+	var version models.DocumentVersion
+	err := db.DB.Preload("Blob").
+		Where("document_id = ? AND number = ?", documentID, number).
+		First(&version).Error
+	return version, err
+}
+
+func versionResponse(v models.DocumentVersion, current int) VersionResponse {
+	return VersionResponse{
+		Version:   v.Number,
+		Size:      v.Blob.Size,
+		Encoding:  v.Blob.Encoding,
+		MimeType:  v.Blob.MimeType,
+		Current:   v.Number == current,
+		CreatedAt: v.CreatedAt,
+	}
+}
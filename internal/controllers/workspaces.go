@@ -0,0 +1,471 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WorkspaceRequest - имя рабочего пространства
+type WorkspaceRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// WorkspaceMemberRequest - пользователь и его роль в рабочем пространстве
+type WorkspaceMemberRequest struct {
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role" binding:"required,oneof=owner editor viewer"`
+}
+
+// WorkspaceResponse - рабочее пространство и роль в нём текущего пользователя
+type WorkspaceResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WorkspaceMemberResponse - участник рабочего пространства
+type WorkspaceMemberResponse struct {
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ownerScope - пространство, которому принадлежат документы и коллекции: личное пространство пользователя
+// или рабочее пространство, выбранное заголовком X-Workspace-ID
+type ownerScope struct {
+	UserID      uint
+	WorkspaceID *uint
+	Role        string // роль в рабочем пространстве; в личном пространстве пустая
+}
+
+// requestScope - активное пространство запроса
+func requestScope(c *gin.Context) ownerScope {
+	scope := ownerScope{UserID: c.MustGet("userID").(uint)}
+	if id, ok := c.Get("workspaceID"); ok {
+		workspaceID := id.(uint)
+		scope.WorkspaceID = &workspaceID
+		scope.Role = c.GetString("workspaceRole")
+	}
+	return scope
+}
+
+// condition - условие принадлежности строк таблицы пространству
+func (s ownerScope) condition(table string) (string, []interface{}) {
+	if s.WorkspaceID != nil {
+		return table + ".workspace_id = ?", []interface{}{*s.WorkspaceID}
+	}
+	return table + ".user_id = ? AND " + table + ".workspace_id IS NULL", []interface{}{s.UserID}
+}
+
+// where - отбор строк таблицы documents или collections, принадлежащих пространству
+func (s ownerScope) where(query *gorm.DB, table string) *gorm.DB {
+	cond, args := s.condition(table)
+	return query.Where(cond, args...)
+}
+
+// owns - принадлежит ли пространству объект с владельцем userID и рабочим пространством workspaceID
+func (s ownerScope) owns(userID uint, workspaceID *uint) bool {
+	if s.WorkspaceID != nil {
+		return workspaceID != nil && *workspaceID == *s.WorkspaceID
+	}
+	return workspaceID == nil && userID == s.UserID
+}
+
+// collectionAccess - уровень доступа к коллекциям пространства: в личном пространстве - owner,
+// в рабочем - роль в нём. Редактор пространства управляет его коллекциями (см. managesCollection),
+// но открывать к ним доступ другим может только владелец пространства.
+func (s ownerScope) collectionAccess() string {
+	if s.WorkspaceID == nil {
+		return models.AccessOwner
+	}
+	return s.Role
+}
+
+// findWorkspace - рабочее пространство из пути, в котором пользователь состоит с ролью не ниже need.
+// Чужое пространство не отличается от несуществующего; при недостаточной роли ответ 403.
+func findWorkspace(c *gin.Context, need string) (models.Workspace, string, bool) {
+	userID := c.MustGet("userID").(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	var workspace models.Workspace
+	var member models.WorkspaceMember
+	err := db.DB.Where("workspace_id = ? AND user_id = ?", id, userID).First(&member).Error
+	if err == nil {
+		err = db.DB.First(&workspace, id).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return workspace, "", false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return workspace, "", false
+	}
+	if accessRank(member.Role) < accessRank(need) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Workspace role " + member.Role + " is not enough, " + need + " required"})
+		return workspace, "", false
+	}
+	return workspace, member.Role, true
+}
+
+// CreateWorkspaceAPI – создание рабочего пространства
+// @Summary Создание рабочего пространства
+// @Description Создаёт рабочее пространство, создатель становится его владельцем. Документы и коллекции пространства
+// @Description доступны всем участникам; пространство выбирается заголовком X-Workspace-ID в запросах к документам, коллекциям и корзине.
+// @Tags Рабочие пространства
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body WorkspaceRequest true "Имя пространства"
+// @Success 201 {object} WorkspaceResponse
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/workspaces [post]
+func CreateWorkspaceAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	var req WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	workspace := models.Workspace{Name: req.Name}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&workspace).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.WorkspaceMember{WorkspaceID: workspace.ID, UserID: userID, Role: models.AccessOwner}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, WorkspaceResponse{
+		ID:        workspace.ID,
+		Name:      workspace.Name,
+		Role:      models.AccessOwner,
+		CreatedAt: workspace.CreatedAt,
+	})
+}
+
+// ListWorkspacesAPI – рабочие пространства пользователя
+// @Summary Рабочие пространства пользователя
+// @Description Возвращает пространства, в которых состоит пользователь, и его роль в каждом.
+// @Tags Рабочие пространства
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} WorkspaceResponse
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/workspaces [get]
+func ListWorkspacesAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+
+	workspaces := []WorkspaceResponse{}
+	if err := db.DB.Table("workspaces").
+		Select("workspaces.id, workspaces.name, workspace_members.role, workspaces.created_at").
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = workspaces.id").
+		Where("workspace_members.user_id = ?", userID).
+		Order("workspaces.name, workspaces.id").
+		Scan(&workspaces).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, workspaces)
+}
+
+// GetWorkspaceAPI – рабочее пространство и его участники
+// @Summary Рабочее пространство и его участники
+// @Description Возвращает пространство, роль текущего пользователя и список участников. Доступно участникам.
+// @Tags Рабочие пространства
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID рабочего пространства"
+// @Success 200 {object} map[string]interface{} "{"id":int,"name":string,"role":string,"created_at":string,"members":[]WorkspaceMemberResponse}"
+// @Failure 404 {object} map[string]string "Workspace not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/workspaces/{id} [get]
+func GetWorkspaceAPI(c *gin.Context) {
+	workspace, role, ok := findWorkspace(c, models.AccessViewer)
+	if !ok {
+		return
+	}
+
+	members := []WorkspaceMemberResponse{}
+	if err := db.DB.Table("workspace_members").
+		Select("workspace_members.user_id, users.username, workspace_members.role, workspace_members.created_at").
+		Joins("JOIN users ON users.id = workspace_members.user_id").
+		Where("workspace_members.workspace_id = ?", workspace.ID).
+		Order("users.username").
+		Scan(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":         workspace.ID,
+		"name":       workspace.Name,
+		"role":       role,
+		"created_at": workspace.CreatedAt,
+		"members":    members,
+	})
+}
+
+// UpdateWorkspaceAPI – переименование рабочего пространства
+// @Summary Переименование рабочего пространства
+// @Description Меняет имя пространства. Доступно владельцам.
+// @Tags Рабочие пространства
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID рабочего пространства"
+// @Param body body WorkspaceRequest true "Новое имя"
+// @Success 200 {object} WorkspaceResponse
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Workspace role is not enough"
+// @Failure 404 {object} map[string]string "Workspace not found"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/workspaces/{id} [patch]
+func UpdateWorkspaceAPI(c *gin.Context) {
+	var req WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	workspace, role, ok := findWorkspace(c, models.AccessOwner)
+	if !ok {
+		return
+	}
+
+	if err := db.DB.Model(&workspace).Update("name", req.Name).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	c.JSON(http.StatusOK, WorkspaceResponse{ID: workspace.ID, Name: workspace.Name, Role: role, CreatedAt: workspace.CreatedAt})
+}
+
+// DeleteWorkspaceAPI – удаление рабочего пространства
+// @Summary Удаление рабочего пространства
+// @Description Окончательно удаляет пространство со всеми его документами (включая корзину), коллекциями и незавершёнными загрузками.
+// @Description Доступно владельцам.
+// @Tags Рабочие пространства
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID рабочего пространства"
+// @Success 200 {object} map[string]string "{"message":"Workspace deleted"}"
+// @Failure 403 {object} map[string]string "Workspace role is not enough"
+// @Failure 404 {object} map[string]string "Workspace not found"
+// @Failure 500 {object} map[string]string "Failed to delete workspace"
+// @Router /api/workspaces/{id} [delete]
+func DeleteWorkspaceAPI(c *gin.Context) {
+	workspace, _, ok := findWorkspace(c, models.AccessOwner)
+	if !ok {
+		return
+	}
+	if err := deleteWorkspace(c.Request.Context(), workspace.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete workspace"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Workspace deleted"})
+}
+
+// AddWorkspaceMemberAPI – добавление участника
+// @Summary Добавление участника рабочего пространства
+// @Description Добавляет пользователя в пространство или меняет его роль: owner - управление участниками и пространством,
+// @Description editor - загрузка и изменение документов и коллекций, viewer - только чтение. Доступно владельцам.
+// @Tags Рабочие пространства
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID рабочего пространства"
+// @Param body body WorkspaceMemberRequest true "Пользователь и роль"
+// @Success 201 {object} WorkspaceMemberResponse
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Workspace role is not enough"
+// @Failure 404 {object} map[string]string "Workspace or user not found"
+// @Failure 409 {object} map[string]string "Workspace must keep at least one owner"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/workspaces/{id}/members [post]
+func AddWorkspaceMemberAPI(c *gin.Context) {
+	var req WorkspaceMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	workspace, _, ok := findWorkspace(c, models.AccessOwner)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	if err := db.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if req.Role != models.AccessOwner {
+		if last, err := lastOwner(workspace.ID, user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		} else if last {
+			c.JSON(http.StatusConflict, gin.H{"error": "Workspace must keep at least one owner"})
+			return
+		}
+	}
+
+	member := models.WorkspaceMember{WorkspaceID: workspace.ID, UserID: user.ID, Role: req.Role}
+	if err := db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, WorkspaceMemberResponse{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt,
+	})
+}
+
+// DeleteWorkspaceMemberAPI – исключение участника
+// @Summary Исключение участника рабочего пространства
+// @Description Владелец исключает любого участника, участник может выйти сам. Загруженные участником документы остаются в пространстве.
+// @Tags Рабочие пространства
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID рабочего пространства"
+// @Param user_id path int true "ID участника"
+// @Success 200 {object} map[string]string "{"message":"Member removed"}"
+// @Failure 403 {object} map[string]string "Only owners can remove other members"
+// @Failure 404 {object} map[string]string "Workspace or member not found"
+// @Failure 409 {object} map[string]string "Workspace must keep at least one owner"
+// @Failure 500 {object} map[string]string "Database error"
+// @Router /api/workspaces/{id}/members/{user_id} [delete]
+func DeleteWorkspaceMemberAPI(c *gin.Context) {
+	userID := c.MustGet("userID").(uint)
+	memberID, _ := strconv.Atoi(c.Param("user_id"))
+
+	workspace, role, ok := findWorkspace(c, models.AccessViewer)
+	if !ok {
+		return
+	}
+	if role != models.AccessOwner && uint(memberID) != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can remove other members"})
+		return
+	}
+	if last, err := lastOwner(workspace.ID, uint(memberID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	} else if last {
+		c.JSON(http.StatusConflict, gin.H{"error": "Workspace must keep at least one owner"})
+		return
+	}
+
+	res := db.DB.Where("workspace_id = ? AND user_id = ?", workspace.ID, memberID).Delete(&models.WorkspaceMember{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// lastOwner - является ли пользователь единственным владельцем рабочего пространства
+func lastOwner(workspaceID, userID uint) (bool, error) {
+	var owners []uint
+	err := db.DB.Model(&models.WorkspaceMember{}).
+		Where("workspace_id = ? AND role = ?", workspaceID, models.AccessOwner).
+		Pluck("user_id", &owners).Error
+	return len(owners) == 1 && owners[0] == userID, err
+}
+
+// deleteWorkspace - окончательное удаление рабочего пространства с документами, коллекциями и сессиями загрузки
+func deleteWorkspace(ctx context.Context, workspaceID uint) error {
+	var documentIDs, collectionIDs []uint
+	db.DB.Unscoped().Model(&models.Document{}).Where("workspace_id = ?", workspaceID).Pluck("id", &documentIDs)
+	db.DB.Unscoped().Model(&models.Collection{}).Where("workspace_id = ?", workspaceID).Pluck("id", &collectionIDs)
+	// Документы пространства могли быть добавлены в коллекции, открытые участникам через совместный доступ
+	var sharedIDs []uint
+	db.DB.Table("collection_documents").
+		Distinct("collection_id").
+		Joins("JOIN collections ON collections.id = collection_documents.collection_id").
+		Where("collection_documents.document_id IN ? AND (collections.workspace_id IS NULL OR collections.workspace_id <> ?)", documentIDs, workspaceID).
+		Pluck("collection_id", &sharedIDs)
+	var sessions []models.UploadSession
+	db.DB.Where("workspace_id = ?", workspaceID).Find(&sessions)
+
+	tx := db.DB.Begin()
+	staleKeys, err := purgeDocuments(tx, documentIDs)
+	if err == nil {
+		err = purgeCollections(tx, collectionIDs)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	tx.Where("workspace_id = ?", workspaceID).Delete(&models.UploadSession{})
+	tx.Where("workspace_id = ?", workspaceID).Delete(&models.WorkspaceMember{})
+	tx.Delete(&models.Workspace{}, workspaceID)
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		staleKeys = append(staleKeys, session.Chunks...)
+	}
+	db.RemoveBlobFiles(ctx, staleKeys)
+	return recalcCollections(ctx, sharedIDs)
+}
+
+// leaveWorkspaces - выход удаляемого пользователя из рабочих пространств. Если он был единственным владельцем,
+// владельцем становится самый давний из оставшихся участников; пространство без участников удаляется.
+func leaveWorkspaces(ctx context.Context, userID uint) error {
+	var memberships []models.WorkspaceMember
+	if err := db.DB.Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
+		return err
+	}
+	for _, membership := range memberships {
+		last, err := lastOwner(membership.WorkspaceID, userID)
+		if err != nil {
+			return err
+		}
+		if err := db.DB.Where("workspace_id = ? AND user_id = ?", membership.WorkspaceID, userID).
+			Delete(&models.WorkspaceMember{}).Error; err != nil {
+			return err
+		}
+		if !last {
+			continue
+		}
+
+		var successor models.WorkspaceMember
+		err = db.DB.Where("workspace_id = ?", membership.WorkspaceID).Order("created_at, user_id").First(&successor).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := deleteWorkspace(ctx, membership.WorkspaceID); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := db.DB.Model(&successor).Update("role", models.AccessOwner).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
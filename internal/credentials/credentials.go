@@ -0,0 +1,81 @@
+package credentials
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ограничения имени пользователя и пароля
+const (
+	DefaultMinPasswordLength = 8
+	MaxPasswordBytes         = 72 // bcrypt не учитывает байты после 72-го
+)
+
+var (
+	ErrUsernameFormat   = errors.New("username must be 3-32 characters: latin letters, digits, '.', '_' or '-', starting with a letter or digit")
+	ErrPasswordTooLong  = fmt.Errorf("password must not be longer than %d bytes", MaxPasswordBytes)
+	ErrPasswordCommon   = errors.New("password is too common")
+	ErrPasswordUsername = errors.New("password must not contain the username")
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{2,31}$`)
+
+//go:embed common_passwords.txt
+var commonPasswordsList string
+
+// commonPasswords - распространённые пароли в нижнем регистре
+var commonPasswords = func() map[string]bool {
+	set := map[string]bool{}
+	for _, line := range strings.Split(commonPasswordsList, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			set[strings.ToLower(line)] = true
+		}
+	}
+	return set
+}()
+
+// CheckUsername - проверка формата имени пользователя
+func CheckUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return ErrUsernameFormat
+	}
+	return nil
+}
+
+// Policy - требования к паролю
+type Policy struct {
+	MinLength int // минимальная длина в символах
+}
+
+// PolicyFromEnv - требования из PASSWORD_MIN_LENGTH (по умолчанию 8 символов)
+func PolicyFromEnv() Policy {
+	p := Policy{MinLength: DefaultMinPasswordLength}
+	if v, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && v > 0 {
+		p.MinLength = v
+	}
+	return p
+}
+
+// CheckPassword - проверка пароля: длина, совпадение с распространёнными паролями и с именем пользователя
+func (p Policy) CheckPassword(password, username string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if len(password) > MaxPasswordBytes {
+		return ErrPasswordTooLong
+	}
+	lower := strings.ToLower(password)
+	if commonPasswords[lower] {
+		return ErrPasswordCommon
+	}
+	if username != "" && strings.Contains(lower, strings.ToLower(username)) {
+		return ErrPasswordUsername
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package credentials
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckUsername(t *testing.T) {
+	for _, ok := range []string{"bob", "alice_1", "john.doe", "Team-42", strings.Repeat("a", 32)} {
+		assert.NoError(t, CheckUsername(ok), ok)
+	}
+	for _, bad := range []string{"", "ab", " bob", "bob ", "_bob", ".bob", "иван", "bob@example.com", "a/b", strings.Repeat("a", 33)} {
+		assert.ErrorIs(t, CheckUsername(bad), ErrUsernameFormat, bad)
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	p := Policy{MinLength: 10}
+	assert.NoError(t, p.CheckPassword("correct horse battery", "bob"))
+	assert.NoError(t, p.CheckPassword("длинный пароль", "bob"), "длина в символах, а не в байтах")
+
+	err := p.CheckPassword("short1!", "bob")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "at least 10")
+
+	assert.ErrorIs(t, p.CheckPassword(strings.Repeat("x", 73), "bob"), ErrPasswordTooLong)
+	assert.ErrorIs(t, p.CheckPassword("QWERTYUIOP", "bob"), ErrPasswordCommon, "без учёта регистра")
+	assert.ErrorIs(t, p.CheckPassword("1234567890", "bob"), ErrPasswordCommon)
+	assert.ErrorIs(t, p.CheckPassword("my-Alice_1-secret", "alice_1"), ErrPasswordUsername)
+}
+
+func TestCommonPasswordsLoaded(t *testing.T) {
+	assert.Greater(t, len(commonPasswords), 100)
+	assert.False(t, commonPasswords[""])
+	assert.True(t, commonPasswords["пароль123"])
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"LestaStartTest/internal/models"
+	"LestaStartTest/internal/storage"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxAcquireAttempts - сколько раз AcquireBlob повторяет поиск записи, если параллельная транзакция
+// успела вставить ту же запись между поиском и вставкой
+const maxAcquireAttempts = 3
+
+// AcquireBlob - новая ссылка на содержимое blob.Hash внутри транзакции tx.
+// Если содержимое ещё не известно, оно записывается в хранилище файлов под новым ключом и known=false,
+// иначе blob заполняется сохранёнными ранее данными и known=true.
+// При откате транзакции вызывающий удаляет записанный файл (blob.StorageKey) сам.
+func AcquireBlob(ctx context.Context, tx *gorm.DB, blob *models.Blob, data []byte) (known bool, err error) {
+	hash := blob.Hash
+	for attempt := 0; attempt < maxAcquireAttempts; attempt++ {
+		// Блокировка записи до конца транзакции: параллельный ReleaseBlob не удалит её между поиском и увеличением счётчика
+		var existing models.Blob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("hash = ?", hash).First(&existing).Error
+		if err == nil {
+			if err := tx.Model(&existing).Update("ref_count", gorm.Expr("ref_count + 1")).Error; err != nil {
+				return false, err
+			}
+			existing.RefCount++
+			*blob = existing
+			return true, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
+		}
+
+		// Каждая запись получает свой файл: файл удалённой ранее записи с тем же хешем
+		// может ещё удаляться после фиксации освободившей его транзакции
+		blob.StorageKey = storage.NewBlobKey(hash)
+		blob.RefCount = 1
+		if err := storage.Blobs.Put(ctx, blob.StorageKey, bytes.NewReader(data), int64(len(data))); err != nil {
+			return false, err
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(blob)
+		if res.Error == nil && res.RowsAffected == 1 {
+			return false, nil
+		}
+		// Запись вставила параллельная транзакция: свой файл не нужен, ссылка берётся на её запись
+		if err := storage.Blobs.Delete(ctx, blob.StorageKey); err != nil {
+			log.Printf("Failed to remove blob %s: %v", blob.StorageKey, err)
+		}
+		if res.Error != nil {
+			return false, res.Error
+		}
+	}
+	return false, fmt.Errorf("blob %s: concurrent updates, try again", hash)
+}
+
+// ReleaseBlob - удаление ссылки на содержимое внутри транзакции tx.
+// Когда ссылок не остаётся, запись удаляется и возвращается ключ файла,
+// который вызывающий удаляет из хранилища после фиксации транзакции (RemoveBlobFiles).
+func ReleaseBlob(tx *gorm.DB, hash string) (string, error) {
+	blob := models.Blob{Hash: hash}
+	res := tx.Model(&blob).Clauses(clause.Returning{}).
+		Update("ref_count", gorm.Expr("ref_count - 1"))
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected == 0 || blob.RefCount > 0 {
+		return "", nil
+	}
+	if err := tx.Where("hash = ? AND ref_count <= 0", hash).Delete(&models.Blob{}).Error; err != nil {
+		return "", err
+	}
+	return blob.StorageKey, nil
+}
+
+// RemoveBlobFiles - удаление файлов содержимого, на которое больше нет ссылок
+func RemoveBlobFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := storage.Blobs.Delete(ctx, key); err != nil {
+			log.Printf("Failed to remove blob %s: %v", key, err)
+		}
+	}
+}
+
+// RemoveOrphanFiles - удаление файлов, для которых нет записи о содержимом.
+// Нужно после отката части транзакции, когда файл уже записан, а запись о нём отменена.
+func RemoveOrphanFiles(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	var known []string
+	if err := DB.Model(&models.Blob{}).Where("storage_key IN ?", keys).Pluck("storage_key", &known).Error; err != nil {
+		return err
+	}
+	referenced := make(map[string]bool, len(known))
+	for _, key := range known {
+		referenced[key] = true
+	}
+	var orphans []string
+	for _, key := range keys {
+		if !referenced[key] {
+			orphans = append(orphans, key)
+		}
+	}
+	RemoveBlobFiles(ctx, orphans)
+	return nil
+}
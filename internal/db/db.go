@@ -36,21 +36,46 @@ func Init() {
 	var err error
 
 	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: newLogger,
+		Logger:         newLogger,
+		TranslateError: true,
 	})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	if err = migrateLegacy(); err != nil {
+		log.Fatalf("Failed to migrate legacy schema: %v", err)
+	}
+
 	err = DB.AutoMigrate(
 		&models.User{},
+		&models.Workspace{},
+		&models.WorkspaceMember{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.APIKey{},
+		&models.TwoFactor{},
+		&models.RecoveryCode{},
+		&models.LoginChallenge{},
+		&models.Blob{},
 		&models.Document{},
+		&models.DocumentVersion{},
+		&models.DocumentTag{},
 		&models.Collection{},
 		&models.CollectionIDF{},
+		&models.CollectionShare{},
+		&models.ShareLink{},
+		&models.Job{},
+		&models.UploadSession{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to auto migrate: %v", err)
 	}
 
+	if err = migrateVersions(); err != nil {
+		log.Fatalf("Failed to migrate document versions: %v", err)
+	}
+
 	log.Print("Database initialized and migrate successfully")
 }
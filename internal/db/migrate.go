@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+
+	"LestaStartTest/internal/models"
+	"LestaStartTest/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+// migrateLegacy - изменения схемы, которые AutoMigrate не выполняет сам
+func migrateLegacy() error {
+	m := DB.Migrator()
+	if !m.HasTable(&models.Document{}) {
+		return nil
+	}
+
+	// Имя документа уникально только в пределах пользователя: глобальное ограничение
+	// и старый неуникальный индекс заменяются на idx_documents_user_filename
+	if m.HasConstraint(&models.Document{}, "uni_documents_filename") {
+		if err := m.DropConstraint(&models.Document{}, "uni_documents_filename"); err != nil {
+			return err
+		}
+	}
+	if m.HasIndex(&models.Document{}, "idx_user_file") {
+		if err := m.DropIndex(&models.Document{}, "idx_user_file"); err != nil {
+			return err
+		}
+	}
+
+	// Документы в корзине не занимают имя: уникальный индекс заменяется частичным (idx_documents_user_filename_active)
+	if m.HasIndex(&models.Document{}, "idx_documents_user_filename") {
+		if err := m.DropIndex(&models.Document{}, "idx_documents_user_filename"); err != nil {
+			return err
+		}
+	}
+
+	// Имя уникально в пространстве владельца: личные документы - в пределах пользователя,
+	// документы рабочего пространства - в пределах пространства
+	if m.HasIndex(&models.Document{}, "idx_documents_user_filename_active") {
+		if err := m.DropIndex(&models.Document{}, "idx_documents_user_filename_active"); err != nil {
+			return err
+		}
+	}
+
+	if !m.HasColumn(&models.Document{}, "original_path") {
+		return migrateBlobs()
+	}
+
+	// original_path хранит ключ в хранилище файлов, а не путь на диске:
+	// "uploads/1/report.txt" -> "1/report.txt" (каталог uploads - корень локального хранилища по умолчанию)
+	err := DB.Table("documents").
+		Where("original_path LIKE ?", "uploads/%").
+		Update("original_path", gorm.Expr("substr(original_path, ?)", len("uploads/")+1)).Error
+	if err != nil {
+		return err
+	}
+	return migrateBlobs()
+}
+
+// legacyDocument - документ в схеме, где содержимое хранилось в самой таблице documents
+type legacyDocument struct {
+	ID               uint
+	Content          string
+	ProcessedContent string
+	OriginalPath     string
+	Encoding         string
+	MimeType         string
+}
+
+// migrateBlobs - перенос содержимого документов в таблицу blobs с дедупликацией по SHA-256.
+// Документы переносятся по одному, поэтому прерванную миграцию можно безопасно повторить.
+func migrateBlobs() error {
+	m := DB.Migrator()
+	if !m.HasColumn(&models.Document{}, "content") {
+		return nil
+	}
+	if err := m.AutoMigrate(&models.Blob{}); err != nil {
+		return err
+	}
+	if !m.HasColumn(&models.Document{}, "blob_hash") {
+		if err := DB.Exec("ALTER TABLE documents ADD COLUMN blob_hash varchar(64)").Error; err != nil {
+			return err
+		}
+	}
+
+	ctx := context.Background()
+	var docs []legacyDocument
+	if err := DB.Table("documents").Where("blob_hash IS NULL").Find(&docs).Error; err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		// Если исходный файл потерян, хешируется извлечённый текст
+		data := []byte(doc.Content)
+		if r, err := storage.Blobs.Get(ctx, doc.OriginalPath); err == nil {
+			data, err = io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				return err
+			}
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		blob := models.Blob{
+			Hash:             hex.EncodeToString(sum[:]),
+			Size:             int64(len(data)),
+			Content:          doc.Content,
+			ProcessedContent: doc.ProcessedContent,
+			Encoding:         doc.Encoding,
+			MimeType:         doc.MimeType,
+		}
+
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if _, err := AcquireBlob(ctx, tx, &blob, data); err != nil {
+				return err
+			}
+			return tx.Table("documents").Where("id = ?", doc.ID).Update("blob_hash", blob.Hash).Error
+		})
+		if err != nil {
+			return err
+		}
+		if doc.OriginalPath != blob.StorageKey {
+			if err := storage.Blobs.Delete(ctx, doc.OriginalPath); err != nil {
+				log.Printf("Failed to remove migrated file %s: %v", doc.OriginalPath, err)
+			}
+		}
+	}
+
+	for _, column := range []string{"content", "processed_content", "original_path", "encoding", "mime_type"} {
+		if err := DB.Exec("ALTER TABLE documents DROP COLUMN IF EXISTS " + column).Error; err != nil {
+			return err
+		}
+	}
+	log.Printf("Migrated %d documents to content-addressed storage", len(docs))
+	return nil
+}
+
+// migrateVersions - первая версия для документов, загруженных до появления истории версий.
+// Ссылку на содержимое, которую держал документ, наследует эта версия.
+func migrateVersions() error {
+	return DB.Exec(`INSERT INTO document_versions (document_id, number, blob_hash, created_at)
+		SELECT d.id, d.version, d.blob_hash, d.created_at FROM documents d
+		WHERE NOT EXISTS (SELECT 1 FROM document_versions v WHERE v.document_id = d.id)`).Error
+}
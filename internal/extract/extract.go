@@ -0,0 +1,189 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"LestaStartTest/internal/charset"
+)
+
+// MIME-типы, для которых есть извлекатели текста
+const (
+	MIMEPlain    = "text/plain"
+	MIMEMarkdown = "text/markdown"
+	MIMEHTML     = "text/html"
+	MIMERTF      = "application/rtf"
+	MIMEPDF      = "application/pdf"
+	MIMEDOCX     = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MIMEODT      = "application/vnd.oasis.opendocument.text"
+	MIMEZip      = "application/zip"
+	MIMETar      = "application/x-tar"
+	MIMEGzip     = "application/gzip"
+	MIMEBinary   = "application/octet-stream"
+)
+
+var ErrUnsupported = errors.New("unsupported file type")
+
+// Result - результат извлечения текста
+type Result struct {
+	Text     string
+	Encoding string // исходная кодировка, для бинарных форматов - UTF-8
+}
+
+// Extractor - извлечение текста из содержимого файла.
+// encoding - кодировка, выбранная пользователем (пустая строка - автоопределение),
+// учитывается только текстовыми форматами.
+type Extractor func(data []byte, encoding string) (Result, error)
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Extractor{}
+)
+
+func init() {
+	Register(MIMEPlain, extractPlain)
+	Register(MIMEMarkdown, extractMarkdown)
+	Register(MIMEHTML, extractHTML)
+	Register(MIMERTF, extractRTF)
+	Register(MIMEPDF, extractPDF)
+	Register(MIMEDOCX, extractDOCX)
+	Register(MIMEODT, extractODT)
+}
+
+// Register - регистрация извлекателя для MIME-типа (заменяет существующий)
+func Register(mime string, e Extractor) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[mime] = e
+}
+
+// Lookup - поиск извлекателя по MIME-типу
+func Lookup(mime string) (Extractor, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	e, ok := registry[mime]
+	return e, ok
+}
+
+// Extract - определение типа файла и извлечение из него текста.
+// Возвращает результат и определённый MIME-тип.
+func Extract(filename string, data []byte, encoding string) (Result, string, error) {
+	mime := DetectMIME(filename, data)
+	e, ok := Lookup(mime)
+	if !ok {
+		return Result{}, mime, fmt.Errorf("%w: %s", ErrUnsupported, mime)
+	}
+	res, err := e(data, encoding)
+	if err != nil {
+		return Result{}, mime, err
+	}
+	return res, mime, nil
+}
+
+// DetectMIME - определение MIME-типа по содержимому файла.
+// Расширение используется только для уточнения текстовых форматов, которые не отличить по сигнатуре.
+func DetectMIME(filename string, data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return MIMEPDF
+	case bytes.HasPrefix(data, []byte(`{\rtf`)):
+		return MIMERTF
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return detectZip(data)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		return MIMEGzip
+	case len(data) >= 262 && bytes.Equal(data[257:262], []byte("ustar")):
+		return MIMETar
+	}
+
+	sniffed, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	switch {
+	case sniffed == MIMEHTML:
+		return MIMEHTML
+	case strings.HasPrefix(sniffed, "text/"):
+		// ничего не делаем, дальше уточняем по расширению
+	case sniffed == MIMEBinary:
+		// UTF-16 без BOM и однобайтовые кодировки выглядят для DetectContentType как бинарные данные
+		if _, err := charset.Detect(data); err != nil {
+			return MIMEBinary
+		}
+	default:
+		return sniffed
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".md", ".markdown":
+		return MIMEMarkdown
+	case ".html", ".htm", ".xhtml":
+		return MIMEHTML
+	}
+	return MIMEPlain
+}
+
+// detectZip - различение DOCX, ODT и обычных zip-архивов
+func detectZip(data []byte) string {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return MIMEZip
+	}
+	for _, f := range zr.File {
+		switch f.Name {
+		case "word/document.xml":
+			return MIMEDOCX
+		case "mimetype":
+			if content, err := readZipFile(f); err == nil && strings.TrimSpace(string(content)) == MIMEODT {
+				return MIMEODT
+			}
+		}
+	}
+	return MIMEZip
+}
+
+// maxZipPart - ограничение на размер распакованной части документа (защита от zip-бомб)
+const maxZipPart = 64 << 20
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(io.LimitReader(rc, maxZipPart+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxZipPart {
+		return nil, fmt.Errorf("%s is too large", f.Name)
+	}
+	return content, nil
+}
+
+// readZipPart - чтение файла из zip-контейнера по имени
+func readZipPart(data []byte, name string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if f.Name == name {
+			return readZipFile(f)
+		}
+	}
+	return nil, fmt.Errorf("%s not found in container", name)
+}
+
+func extractPlain(data []byte, encoding string) (Result, error) {
+	text, enc, err := charset.Decode(data, encoding)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Text: text, Encoding: enc}, nil
+}
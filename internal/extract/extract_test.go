@@ -0,0 +1,154 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zipFiles(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i+1 < len(files); i += 2 {
+		w, err := zw.Create(files[i])
+		require.NoError(t, err)
+		_, err = w.Write([]byte(files[i+1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// buildPDF - минимальный PDF из одной страницы с заданным потоком содержимого и объектами шрифта
+func buildPDF(t *testing.T, content string, font string, extra ...string) []byte {
+	t.Helper()
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	_, err := zw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	b.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	b.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 /Resources << /Font << /F1 4 0 R >> >> >> endobj\n")
+	b.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 5 0 R >> endobj\n")
+	b.WriteString("4 0 obj " + font + " endobj\n")
+	fmt.Fprintf(&b, "5 0 obj << /Length %d /Filter /FlateDecode >>\nstream\n", z.Len())
+	b.Write(z.Bytes())
+	b.WriteString("\nendstream\nendobj\n")
+	for _, obj := range extra {
+		b.WriteString(obj + "\n")
+	}
+	b.WriteString("trailer << /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func TestDetectMIME(t *testing.T) {
+	assert.Equal(t, MIMEPDF, DetectMIME("scan.txt", []byte("%PDF-1.7\n...")))
+	assert.Equal(t, MIMERTF, DetectMIME("a.doc", []byte(`{\rtf1\ansi hello}`)))
+	assert.Equal(t, MIMEHTML, DetectMIME("page.txt", []byte("<!DOCTYPE html><html><body>hi</body></html>")))
+	assert.Equal(t, MIMEMarkdown, DetectMIME("README.md", []byte("# Заголовок")))
+	assert.Equal(t, MIMEPlain, DetectMIME("notes", []byte("просто текст")))
+	assert.Equal(t, MIMEDOCX, DetectMIME("file.bin", zipFiles(t, "word/document.xml", "<w:document/>")))
+	assert.Equal(t, MIMEODT, DetectMIME("file.bin", zipFiles(t, "mimetype", MIMEODT, "content.xml", "<x/>")))
+	assert.Equal(t, MIMEZip, DetectMIME("file.docx", zipFiles(t, "a.txt", "a")))
+	assert.Equal(t, MIMEGzip, DetectMIME("corpus.tgz", []byte{0x1f, 0x8b, 0x08, 0x00}))
+	assert.Equal(t, MIMEBinary, DetectMIME("image.txt", []byte{0x00, 0x01, 0x02, 0xFF, 0x00, 0x10, 0x20}))
+}
+
+func TestExtractDOCX(t *testing.T) {
+	doc := `<?xml version="1.0"?><w:document xmlns:w="w"><w:body>` +
+		`<w:p><w:r><w:t>Первый</w:t></w:r><w:r><w:t xml:space="preserve"> абзац</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t>Второй</w:t><w:tab/><w:t>абзац</w:t></w:r></w:p></w:body></w:document>`
+
+	res, mime, err := Extract("report.docx", zipFiles(t, "[Content_Types].xml", "<Types/>", "word/document.xml", doc), "")
+	require.NoError(t, err)
+	assert.Equal(t, MIMEDOCX, mime)
+	assert.Equal(t, "Первый абзац\nВторой\tабзац\n", res.Text)
+}
+
+func TestExtractODT(t *testing.T) {
+	content := `<office:document-content xmlns:office="o" xmlns:text="t"><office:meta>мета</office:meta><office:body><office:text>` +
+		`<text:h>Заголовок</text:h><text:p>Текст<text:s text:c="2"/>документа</text:p></office:text></office:body></office:document-content>`
+
+	res, mime, err := Extract("report.odt", zipFiles(t, "mimetype", MIMEODT, "content.xml", content), "")
+	require.NoError(t, err)
+	assert.Equal(t, MIMEODT, mime)
+	assert.Equal(t, "Заголовок\nТекст  документа\n", res.Text)
+}
+
+func TestExtractHTML(t *testing.T) {
+	page := `<html><head><title>Заголовок</title><style>body{color:red}</style>
+<script>var secret = "не текст";</script></head>
+<body><p>Первый&nbsp;абзац</p><div>Второй <b>абзац</b></div></body></html>`
+
+	res, _, err := Extract("page.html", []byte(page), "")
+	require.NoError(t, err)
+	assert.Contains(t, res.Text, "Заголовок")
+	assert.Contains(t, res.Text, "Первый абзац")
+	assert.Contains(t, res.Text, "Второй  абзац")
+	assert.NotContains(t, res.Text, "secret")
+	assert.NotContains(t, res.Text, "color")
+}
+
+func TestExtractMarkdown(t *testing.T) {
+	md := "# Заголовок\n\nТекст со **ссылкой** на [сайт](https://example.com) и ![картинку](img.png).\n\n" +
+		"- пункт\n> цитата\n\n```go\nfmt.Println()\n```\n"
+
+	res, mime, err := Extract("notes.md", []byte(md), "")
+	require.NoError(t, err)
+	assert.Equal(t, MIMEMarkdown, mime)
+	assert.Equal(t, "Заголовок\n\nТекст со ссылкой на сайт и картинку.\n\nпункт\nцитата\n\n\nfmt.Println()", res.Text)
+}
+
+func TestExtractRTF(t *testing.T) {
+	rtf := `{\rtf1\ansi\ansicpg1251{\fonttbl{\f0 Times New Roman;}}{\*\generator Test;}` +
+		`\f0\fs24 \'cf\'f0\'e8\'e2\'e5\'f2, \u1084?\u1080?\u1088?!\par Second\tab line}`
+
+	res, mime, err := Extract("letter.rtf", []byte(rtf), "")
+	require.NoError(t, err)
+	assert.Equal(t, MIMERTF, mime)
+	assert.Equal(t, "Привет, мир!\nSecond\tline", res.Text)
+}
+
+func TestExtractPDF(t *testing.T) {
+	t.Run("латиница", func(t *testing.T) {
+		content := "BT /F1 12 Tf 72 712 Td (Hello \\(PDF\\)) Tj 0 -14 Td [(Wor) 20 (ld) -300 (again)] TJ ET"
+		data := buildPDF(t, content, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+
+		res, mime, err := Extract("doc.pdf", data, "")
+		require.NoError(t, err)
+		assert.Equal(t, MIMEPDF, mime)
+		assert.Equal(t, "Hello (PDF)\nWorld again", res.Text)
+	})
+
+	t.Run("ToUnicode", func(t *testing.T) {
+		cmap := "/CIDInit /ProcSet findresource begin begincmap\n1 begincodespacerange <00> <FF> endcodespacerange\n" +
+			"2 beginbfchar <01> <043F> <02> <0440> endbfchar\n1 beginbfrange <03> <05> <0438> endbfrange\nendcmap"
+		font := "<< /Type /Font /Subtype /Type0 /BaseFont /Custom /ToUnicode 6 0 R >>"
+		toUnicode := fmt.Sprintf("6 0 obj << /Length %d >>\nstream\n%s\nendstream\nendobj", len(cmap), cmap)
+
+		res, _, err := Extract("doc.pdf", buildPDF(t, "BT /F1 10 Tf <0102030405> Tj ET", font, toUnicode), "")
+		require.NoError(t, err)
+		assert.Equal(t, "прийк", res.Text)
+	})
+
+	t.Run("без текстового слоя", func(t *testing.T) {
+		data := buildPDF(t, "q 100 0 0 100 0 0 cm /Im1 Do Q", "<< /Type /Font >>")
+		_, _, err := Extract("scan.pdf", data, "")
+		assert.ErrorIs(t, err, ErrPDFNoText)
+	})
+}
+
+func TestExtractUnsupported(t *testing.T) {
+	_, mime, err := Extract("archive.zip", zipFiles(t, "a.txt", "a"), "")
+	assert.Equal(t, MIMEZip, mime)
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
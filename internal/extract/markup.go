@@ -0,0 +1,117 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+
+	"LestaStartTest/internal/charset"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipTags - элементы HTML, содержимое которых не является текстом документа
+var skipTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+}
+
+// blockTags - элементы HTML, после которых начинается новая строка
+var blockTags = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Li: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Title: true, atom.Section: true, atom.Article: true, atom.Blockquote: true,
+	atom.Pre: true, atom.Table: true, atom.Ul: true, atom.Ol: true, atom.Hr: true,
+}
+
+// extractHTML - удаление разметки, скриптов и стилей из HTML
+func extractHTML(data []byte, encoding string) (Result, error) {
+	source, enc, err := charset.Decode(data, encoding)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Text: stripHTML(source), Encoding: enc}, nil
+}
+
+func stripHTML(source string) string {
+	z := html.NewTokenizer(strings.NewReader(source))
+	var (
+		b    strings.Builder
+		skip int
+	)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return strings.TrimSpace(b.String())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			if skipTags[a] && tt == html.StartTagToken {
+				skip++
+			}
+			if blockTags[a] {
+				b.WriteByte('\n')
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			if skipTags[a] && skip > 0 {
+				skip--
+			}
+			if blockTags[a] {
+				b.WriteByte('\n')
+			}
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(z.Text())
+				b.WriteByte(' ')
+			}
+		}
+	}
+}
+
+var (
+	mdFence     = regexp.MustCompile("(?m)^[ \\t]*(```|~~~).*$")
+	mdImage     = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink      = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdRefLink   = regexp.MustCompile(`\[([^\]]*)\]\[[^\]]*\]`)
+	mdRefDef    = regexp.MustCompile(`(?m)^[ \t]*\[[^\]]+\]:[ \t]*\S+.*$`)
+	mdHeading   = regexp.MustCompile(`(?m)^[ \t]{0,3}#{1,6}[ \t]*`)
+	mdQuote     = regexp.MustCompile(`(?m)^[ \t]*(>[ \t]*)+`)
+	mdList      = regexp.MustCompile(`(?m)^[ \t]*([-*+]|\d+[.)])[ \t]+`)
+	mdRule      = regexp.MustCompile(`(?m)^[ \t]*([-*_][ \t]*){3,}$`)
+	mdTableSep  = regexp.MustCompile(`(?m)^[ \t]*\|?([ \t]*:?-+:?[ \t]*\|)+[ \t]*:?-*:?[ \t]*$`)
+	mdEmphasis  = regexp.MustCompile(`(\*\*|__|\*|_|~~|` + "`" + `)`)
+	mdHTMLTag   = regexp.MustCompile(`<[^>]+>`)
+	mdTablePipe = regexp.MustCompile(`\s*\|\s*`)
+)
+
+// extractMarkdown - удаление синтаксиса Markdown с сохранением текста ссылок и подписей к картинкам
+func extractMarkdown(data []byte, encoding string) (Result, error) {
+	source, enc, err := charset.Decode(data, encoding)
+	if err != nil {
+		return Result{}, err
+	}
+
+	text := mdFence.ReplaceAllString(source, "")
+	text = mdImage.ReplaceAllString(text, "$1")
+	text = mdLink.ReplaceAllString(text, "$1")
+	text = mdRefLink.ReplaceAllString(text, "$1")
+	text = mdRefDef.ReplaceAllString(text, "")
+	text = mdHeading.ReplaceAllString(text, "")
+	text = mdQuote.ReplaceAllString(text, "")
+	text = mdRule.ReplaceAllString(text, "")
+	text = mdList.ReplaceAllString(text, "")
+	text = mdTableSep.ReplaceAllString(text, "")
+	text = mdHTMLTag.ReplaceAllString(text, "")
+	text = mdEmphasis.ReplaceAllString(text, "")
+	text = mdTablePipe.ReplaceAllString(text, " ")
+
+	return Result{Text: strings.TrimSpace(text), Encoding: enc}, nil
+}
@@ -0,0 +1,119 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"LestaStartTest/internal/charset"
+)
+
+// extractDOCX - текст из word/document.xml: абзацы w:p, строки w:t, табуляции и переносы
+func extractDOCX(data []byte, _ string) (Result, error) {
+	part, err := readZipPart(data, "word/document.xml")
+	if err != nil {
+		return Result{}, err
+	}
+
+	text, err := walkXML(part, func(b *strings.Builder, el xml.StartElement, start bool) bool {
+		switch el.Name.Local {
+		case "t":
+			return start
+		case "tab":
+			if start {
+				b.WriteByte('\t')
+			}
+		case "br", "cr":
+			if start {
+				b.WriteByte('\n')
+			}
+		case "p":
+			if !start {
+				b.WriteByte('\n')
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Text: text, Encoding: charset.UTF8}, nil
+}
+
+// extractODT - текст из content.xml: абзацы и заголовки text:p/text:h, пробелы text:s
+func extractODT(data []byte, _ string) (Result, error) {
+	part, err := readZipPart(data, "content.xml")
+	if err != nil {
+		return Result{}, err
+	}
+
+	inBody := false
+	text, err := walkXML(part, func(b *strings.Builder, el xml.StartElement, start bool) bool {
+		switch el.Name.Local {
+		case "body":
+			inBody = start
+		case "s":
+			if start {
+				n := 1
+				for _, a := range el.Attr {
+					if a.Name.Local == "c" {
+						if c, err := strconv.Atoi(a.Value); err == nil && c > 0 && c < 1024 {
+							n = c
+						}
+					}
+				}
+				b.WriteString(strings.Repeat(" ", n))
+			}
+		case "tab":
+			if start {
+				b.WriteByte('\t')
+			}
+		case "line-break":
+			if start {
+				b.WriteByte('\n')
+			}
+		case "p", "h":
+			if !start {
+				b.WriteByte('\n')
+			}
+		}
+		return inBody
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Text: text, Encoding: charset.UTF8}, nil
+}
+
+// walkXML - обход XML-документа. Функция visit вызывается на открытии и закрытии каждого элемента
+// и возвращает, нужно ли собирать текст, идущий следом за этим событием.
+func walkXML(data []byte, visit func(b *strings.Builder, el xml.StartElement, start bool) bool) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var (
+		b       strings.Builder
+		collect bool
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			collect = visit(&b, t, true)
+		case xml.EndElement:
+			collect = visit(&b, xml.StartElement{Name: t.Name}, false)
+		case xml.CharData:
+			if collect {
+				b.Write(t)
+			}
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,682 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"LestaStartTest/internal/charset"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+var (
+	ErrPDFEncrypted = errors.New("encrypted pdf is not supported")
+	ErrPDFNoText    = errors.New("pdf has no text layer")
+)
+
+var (
+	pdfObjHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	pdfRef       = regexp.MustCompile(`^(\d+)\s+(\d+)\s+R$`)
+	pdfRefs      = regexp.MustCompile(`(\d+)\s+\d+\s+R`)
+	pdfNamedRef  = regexp.MustCompile(`/([^\s/<>\[\]()]+)\s+(\d+)\s+\d+\s+R`)
+	pdfLength    = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+	pdfFilters   = regexp.MustCompile(`/(FlateDecode|ASCII85Decode|ASCIIHexDecode|LZWDecode|DCTDecode|RunLengthDecode|CCITTFaxDecode|JBIG2Decode|JPXDecode)`)
+	pdfTypePage  = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfTypePages = regexp.MustCompile(`/Type\s*/Pages\b`)
+	pdfObjStm    = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+	pdfIntKey    = regexp.MustCompile(`/(N|First)\s+(\d+)`)
+	pdfDictKey   = regexp.MustCompile(`/([A-Za-z]+)`)
+	pdfRefPrefix = regexp.MustCompile(`^\d+\s+\d+\s+R`)
+)
+
+// pdfObject - объект PDF: словарь и (если есть) распакованный поток
+type pdfObject struct {
+	dict   string
+	stream []byte
+}
+
+// maxPDFStream - ограничение на размер распакованного потока
+const maxPDFStream = 64 << 20
+
+// extractPDF - извлечение текстового слоя PDF: операторы Tj/TJ/'/" из потоков содержимого страниц
+// с учётом таблиц ToUnicode шрифтов. Отсканированные PDF без текстового слоя не поддерживаются.
+func extractPDF(data []byte, _ string) (Result, error) {
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return Result{}, ErrPDFEncrypted
+	}
+
+	objects := parsePDFObjects(data)
+	cmaps := map[int]*pdfCMap{}
+
+	var b strings.Builder
+	for _, page := range pdfPages(objects) {
+		fonts := pdfPageFonts(objects, page)
+		for _, ref := range pdfRefs.FindAllStringSubmatch(pdfDictValue(page.dict, "Contents"), -1) {
+			num, _ := strconv.Atoi(ref[1])
+			content, ok := objects[num]
+			if !ok || content.stream == nil {
+				continue
+			}
+			pdfContentText(&b, content.stream, func(font string) *pdfCMap {
+				num, ok := fonts[font]
+				if !ok {
+					return nil
+				}
+				if cm, ok := cmaps[num]; ok {
+					return cm
+				}
+				cm := pdfFontCMap(objects, num)
+				cmaps[num] = cm
+				return cm
+			})
+		}
+		b.WriteString("\n")
+	}
+
+	text := strings.TrimSpace(b.String())
+	if text == "" {
+		return Result{}, ErrPDFNoText
+	}
+	return Result{Text: text, Encoding: charset.UTF8}, nil
+}
+
+// parsePDFObjects - поиск всех объектов "N G obj ... endobj", включая объекты из потоков /ObjStm
+func parsePDFObjects(data []byte) map[int]*pdfObject {
+	objects := map[int]*pdfObject{}
+	for _, m := range pdfObjHeader.FindAllSubmatchIndex(data, -1) {
+		num, err := strconv.Atoi(string(data[m[2]:m[3]]))
+		if err != nil {
+			continue
+		}
+		rest := data[m[1]:]
+		end := bytes.Index(rest, []byte("endobj"))
+		if end < 0 {
+			end = len(rest)
+		}
+
+		obj := &pdfObject{}
+		si := bytes.Index(rest[:end], []byte("stream"))
+		if si < 0 {
+			obj.dict = string(rest[:end])
+			objects[num] = obj
+			continue
+		}
+
+		obj.dict = string(rest[:si])
+		start := si + len("stream")
+		if start < len(rest) && rest[start] == '\r' {
+			start++
+		}
+		if start < len(rest) && rest[start] == '\n' {
+			start++
+		}
+
+		// Длина потока из словаря, если она задана числом и указывает на endstream
+		raw := []byte(nil)
+		if lm := pdfLength.FindStringSubmatch(obj.dict); lm != nil && lm[2] == "" {
+			if n, err := strconv.Atoi(lm[1]); err == nil && start+n <= len(rest) &&
+				bytes.HasPrefix(bytes.TrimLeft(rest[start+n:], "\r\n \t"), []byte("endstream")) {
+				raw = rest[start : start+n]
+			}
+		}
+		if raw == nil {
+			es := bytes.Index(rest[start:], []byte("endstream"))
+			if es < 0 {
+				continue
+			}
+			raw = bytes.TrimRight(rest[start:start+es], "\r\n")
+		}
+		obj.stream = pdfDecodeStream(obj.dict, raw)
+		objects[num] = obj
+	}
+
+	// Объекты, упакованные в потоки объектов (PDF 1.5+)
+	for _, obj := range objects {
+		if obj.stream == nil || !pdfObjStm.MatchString(obj.dict) {
+			continue
+		}
+		var n, first int
+		for _, m := range pdfIntKey.FindAllStringSubmatch(obj.dict, -1) {
+			v, _ := strconv.Atoi(m[2])
+			if m[1] == "N" {
+				n = v
+			} else {
+				first = v
+			}
+		}
+		if first > len(obj.stream) {
+			continue
+		}
+		header := strings.Fields(string(obj.stream[:first]))
+		for i := 0; i+1 < len(header) && i/2 < n; i += 2 {
+			num, err1 := strconv.Atoi(header[i])
+			off, err2 := strconv.Atoi(header[i+1])
+			if err1 != nil || err2 != nil || off < 0 || off > len(obj.stream)-first {
+				continue
+			}
+			end := len(obj.stream)
+			if i+3 < len(header) {
+				if next, err := strconv.Atoi(header[i+3]); err == nil && next >= off && next <= end-first {
+					end = first + next
+				}
+			}
+			if first+off > end || end > len(obj.stream) {
+				continue
+			}
+			if _, ok := objects[num]; !ok {
+				objects[num] = &pdfObject{dict: string(obj.stream[first+off : end])}
+			}
+		}
+	}
+	return objects
+}
+
+// pdfDecodeStream - применение фильтров потока. Для неподдерживаемых фильтров (изображения) возвращает nil.
+func pdfDecodeStream(dict string, raw []byte) []byte {
+	out := raw
+	for _, m := range pdfFilters.FindAllStringSubmatch(pdfDictValue(dict, "Filter"), -1) {
+		var err error
+		switch m[1] {
+		case "FlateDecode":
+			var zr io.ReadCloser
+			if zr, err = zlib.NewReader(bytes.NewReader(out)); err == nil {
+				// Обрезанные потоки встречаются часто, используем всё, что удалось распаковать
+				out, err = io.ReadAll(io.LimitReader(zr, maxPDFStream))
+				if len(out) > 0 {
+					err = nil
+				}
+				zr.Close()
+			}
+		case "ASCII85Decode":
+			src := bytes.TrimSuffix(bytes.TrimSpace(out), []byte("~>"))
+			dst := make([]byte, 4*len(src))
+			var n int
+			n, _, err = ascii85.Decode(dst, src, true)
+			out = dst[:n]
+		case "ASCIIHexDecode":
+			src := bytes.Map(func(r rune) rune {
+				if strings.ContainsRune("0123456789abcdefABCDEF", r) {
+					return r
+				}
+				return -1
+			}, bytes.TrimSuffix(bytes.TrimSpace(out), []byte(">")))
+			if len(src)%2 == 1 {
+				src = append(src, '0')
+			}
+			out = make([]byte, len(src)/2)
+			_, err = hex.Decode(out, src)
+		default:
+			return nil
+		}
+		if err != nil {
+			return nil
+		}
+	}
+	return out
+}
+
+// pdfPages - страницы в порядке обхода дерева /Pages
+func pdfPages(objects map[int]*pdfObject) []*pdfObject {
+	var (
+		pages   []*pdfObject
+		visited = map[int]bool{}
+		walk    func(num int)
+	)
+	walk = func(num int) {
+		obj, ok := objects[num]
+		if !ok || visited[num] {
+			return
+		}
+		visited[num] = true
+		if pdfTypePages.MatchString(obj.dict) {
+			for _, ref := range pdfRefs.FindAllStringSubmatch(pdfDictValue(obj.dict, "Kids"), -1) {
+				kid, _ := strconv.Atoi(ref[1])
+				walk(kid)
+			}
+			return
+		}
+		if pdfTypePage.MatchString(obj.dict) {
+			pages = append(pages, obj)
+		}
+	}
+
+	nums := make([]int, 0, len(objects))
+	for num := range objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	for _, num := range nums {
+		dict := objects[num].dict
+		if pdfTypePages.MatchString(dict) && !strings.Contains(dict, "/Parent") {
+			walk(num)
+		}
+	}
+	// Страницы, не попавшие в дерево (повреждённый файл)
+	for _, num := range nums {
+		if !visited[num] && pdfTypePage.MatchString(objects[num].dict) {
+			pages = append(pages, objects[num])
+		}
+	}
+	return pages
+}
+
+// pdfPageFonts - шрифты страницы (имя ресурса -> номер объекта) с учётом наследования ресурсов от /Parent
+func pdfPageFonts(objects map[int]*pdfObject, page *pdfObject) map[string]int {
+	fonts := map[string]int{}
+	node := page
+	for depth := 0; node != nil && depth < 32; depth++ {
+		if res := pdfResolve(objects, pdfDictValue(node.dict, "Resources")); res != "" {
+			for _, m := range pdfNamedRef.FindAllStringSubmatch(pdfResolve(objects, pdfDictValue(res, "Font")), -1) {
+				if _, ok := fonts[m[1]]; !ok {
+					fonts[m[1]], _ = strconv.Atoi(m[2])
+				}
+			}
+			return fonts
+		}
+		m := pdfRef.FindStringSubmatch(pdfDictValue(node.dict, "Parent"))
+		if m == nil {
+			break
+		}
+		num, _ := strconv.Atoi(m[1])
+		node = objects[num]
+	}
+	return fonts
+}
+
+// pdfResolve - раскрытие косвенной ссылки "N G R" в словарь объекта
+func pdfResolve(objects map[int]*pdfObject, value string) string {
+	if m := pdfRef.FindStringSubmatch(value); m != nil {
+		num, _ := strconv.Atoi(m[1])
+		if obj, ok := objects[num]; ok {
+			return obj.dict
+		}
+		return ""
+	}
+	return value
+}
+
+// pdfDictValue - значение ключа словаря: вложенный словарь, массив, ссылка или одиночный токен
+func pdfDictValue(dict, key string) string {
+	start := -1
+	for _, m := range pdfDictKey.FindAllStringSubmatchIndex(dict, -1) {
+		if dict[m[2]:m[3]] == key && m[1] < len(dict) && strings.IndexByte(" \t\r\n\f/<[(0123456789", dict[m[1]]) >= 0 {
+			start = m[1]
+			break
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+	rest := strings.TrimLeft(dict[start:], " \t\r\n")
+	if rest == "" {
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "<<"):
+		depth := 0
+		for i := 0; i+1 < len(rest); i++ {
+			switch rest[i : i+2] {
+			case "<<":
+				depth++
+				i++
+			case ">>":
+				depth--
+				i++
+				if depth == 0 {
+					return rest[:i+1]
+				}
+			}
+		}
+		return rest
+	case strings.HasPrefix(rest, "["):
+		if end := strings.IndexByte(rest, ']'); end >= 0 {
+			return rest[:end+1]
+		}
+		return rest
+	}
+
+	if m := pdfRefPrefix.FindString(rest); m != "" {
+		return m
+	}
+	end := strings.IndexAny(rest[1:], " \t\r\n/<>[]()")
+	if end < 0 {
+		return rest
+	}
+	return rest[:end+1]
+}
+
+// pdfCMap - таблица ToUnicode: коды символов шрифта -> текст
+type pdfCMap struct {
+	width int
+	codes map[uint32]string
+}
+
+var (
+	cmapCodespace = regexp.MustCompile(`begincodespacerange\s*<([0-9A-Fa-f]+)>`)
+	cmapBfchar    = regexp.MustCompile(`(?s)beginbfchar(.*?)endbfchar`)
+	cmapBfrange   = regexp.MustCompile(`(?s)beginbfrange(.*?)endbfrange`)
+	cmapPair      = regexp.MustCompile(`<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]*)>`)
+	cmapHex       = regexp.MustCompile(`<([0-9A-Fa-f]*)>`)
+	cmapRange     = regexp.MustCompile(`<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]+)>\s*(<[0-9A-Fa-f]*>|\[[^\]]*\])`)
+)
+
+// pdfFontCMap - разбор ToUnicode шрифта. Если таблицы нет, возвращает nil.
+func pdfFontCMap(objects map[int]*pdfObject, fontNum int) *pdfCMap {
+	font, ok := objects[fontNum]
+	if !ok {
+		return nil
+	}
+	m := pdfRef.FindStringSubmatch(pdfDictValue(font.dict, "ToUnicode"))
+	if m == nil {
+		return nil
+	}
+	num, _ := strconv.Atoi(m[1])
+	obj, ok := objects[num]
+	if !ok || obj.stream == nil {
+		return nil
+	}
+
+	src := string(obj.stream)
+	cm := &pdfCMap{width: 2, codes: map[uint32]string{}}
+	if cs := cmapCodespace.FindStringSubmatch(src); cs != nil {
+		cm.width = (len(cs[1]) + 1) / 2
+	}
+
+	for _, block := range cmapBfchar.FindAllStringSubmatch(src, -1) {
+		for _, p := range cmapPair.FindAllStringSubmatch(block[1], -1) {
+			code, _ := strconv.ParseUint(p[1], 16, 32)
+			cm.codes[uint32(code)] = utf16Hex(p[2])
+		}
+	}
+	for _, block := range cmapBfrange.FindAllStringSubmatch(src, -1) {
+		for _, r := range cmapRange.FindAllStringSubmatch(block[1], -1) {
+			lo, _ := strconv.ParseUint(r[1], 16, 32)
+			hi, _ := strconv.ParseUint(r[2], 16, 32)
+			if hi < lo || hi-lo > 0xFFFF {
+				continue
+			}
+			if strings.HasPrefix(r[3], "[") {
+				for i, dst := range cmapHex.FindAllStringSubmatch(r[3], -1) {
+					if lo+uint64(i) > hi {
+						break
+					}
+					cm.codes[uint32(lo)+uint32(i)] = utf16Hex(dst[1])
+				}
+				continue
+			}
+			base := []rune(utf16Hex(strings.Trim(r[3], "<>")))
+			if len(base) == 0 {
+				continue
+			}
+			for code := lo; code <= hi; code++ {
+				shifted := append([]rune(nil), base...)
+				shifted[len(shifted)-1] += rune(code - lo)
+				cm.codes[uint32(code)] = string(shifted)
+			}
+		}
+	}
+	return cm
+}
+
+// utf16Hex - декодирование шестнадцатеричной записи UTF-16BE
+func utf16Hex(s string) string {
+	raw, err := hex.DecodeString(s)
+	if err != nil || len(raw)%2 != 0 {
+		return ""
+	}
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = uint16(raw[2*i])<<8 | uint16(raw[2*i+1])
+	}
+	return string(utf16.Decode(units))
+}
+
+func (cm *pdfCMap) decode(s []byte) string {
+	if cm == nil {
+		out, _ := charmap.Windows1252.NewDecoder().Bytes(s)
+		return string(out)
+	}
+	var b strings.Builder
+	for i := 0; i+cm.width <= len(s); i += cm.width {
+		var code uint32
+		for _, c := range s[i : i+cm.width] {
+			code = code<<8 | uint32(c)
+		}
+		b.WriteString(cm.codes[code])
+	}
+	return b.String()
+}
+
+// pdfToken - лексема потока содержимого
+type pdfToken struct {
+	kind byte // 's' - строка, 'n' - число, '/' - имя, '[' ']' - границы массива, 'o' - оператор
+	str  []byte
+	num  float64
+}
+
+// pdfContentText - интерпретация операторов вывода текста в потоке содержимого страницы
+func pdfContentText(b *strings.Builder, content []byte, fontCMap func(font string) *pdfCMap) {
+	var (
+		operands []pdfToken
+		inArray  bool
+		array    []pdfToken
+		cmap     *pdfCMap
+	)
+	lx := &pdfLexer{data: content}
+	for {
+		tok, ok := lx.next()
+		if !ok {
+			return
+		}
+		switch tok.kind {
+		case '[':
+			inArray, array = true, array[:0]
+			continue
+		case ']':
+			inArray = false
+			operands = append(operands, pdfToken{kind: ']'})
+			continue
+		}
+		if inArray {
+			array = append(array, tok)
+			continue
+		}
+		if tok.kind != 'o' {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch string(tok.str) {
+		case "Tf":
+			if len(operands) >= 2 && operands[0].kind == '/' {
+				cmap = fontCMap(string(operands[0].str))
+			}
+		case "Tj":
+			if n := len(operands); n > 0 && operands[n-1].kind == 's' {
+				b.WriteString(cmap.decode(operands[n-1].str))
+			}
+		case "'", "\"":
+			b.WriteString("\n")
+			if n := len(operands); n > 0 && operands[n-1].kind == 's' {
+				b.WriteString(cmap.decode(operands[n-1].str))
+			}
+		case "TJ":
+			for _, el := range array {
+				switch {
+				case el.kind == 's':
+					b.WriteString(cmap.decode(el.str))
+				case el.kind == 'n' && el.num < -250:
+					b.WriteString(" ")
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 && operands[1].num != 0 {
+				b.WriteString("\n")
+			} else if len(operands) >= 1 && operands[0].num != 0 {
+				b.WriteString(" ")
+			}
+		case "T*", "Tm":
+			b.WriteString("\n")
+		case "ET":
+			b.WriteString(" ")
+		case "ID":
+			lx.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+}
+
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (lx *pdfLexer) next() (pdfToken, bool) {
+	d := lx.data
+	for lx.pos < len(d) {
+		c := d[lx.pos]
+		switch {
+		case isPDFSpace(c):
+			lx.pos++
+		case c == '%':
+			for lx.pos < len(d) && d[lx.pos] != '\n' && d[lx.pos] != '\r' {
+				lx.pos++
+			}
+		case c == '(':
+			return pdfToken{kind: 's', str: lx.literal()}, true
+		case c == '<' && lx.pos+1 < len(d) && d[lx.pos+1] == '<', c == '>' && lx.pos+1 < len(d) && d[lx.pos+1] == '>':
+			// Словари в потоке содержимого (параметры маркированного контента) на текст не влияют
+			lx.pos += 2
+		case c == '<':
+			end := bytes.IndexByte(d[lx.pos:], '>')
+			if end < 0 {
+				end = len(d) - lx.pos
+			}
+			digits := bytes.Map(func(r rune) rune {
+				if isPDFSpace(byte(r)) {
+					return -1
+				}
+				return r
+			}, d[lx.pos+1:lx.pos+end])
+			lx.pos += end + 1
+			if len(digits)%2 == 1 {
+				digits = append(digits, '0')
+			}
+			raw := make([]byte, len(digits)/2)
+			hex.Decode(raw, digits)
+			return pdfToken{kind: 's', str: raw}, true
+		case c == '[' || c == ']':
+			lx.pos++
+			return pdfToken{kind: c}, true
+		case c == '/':
+			start := lx.pos + 1
+			lx.pos++
+			for lx.pos < len(d) && !isPDFSpace(d[lx.pos]) && !isPDFDelim(d[lx.pos]) {
+				lx.pos++
+			}
+			return pdfToken{kind: '/', str: d[start:lx.pos]}, true
+		case isPDFDelim(c):
+			lx.pos++
+		default:
+			start := lx.pos
+			for lx.pos < len(d) && !isPDFSpace(d[lx.pos]) && !isPDFDelim(d[lx.pos]) {
+				lx.pos++
+			}
+			word := d[start:lx.pos]
+			if n, err := strconv.ParseFloat(string(word), 64); err == nil {
+				return pdfToken{kind: 'n', num: n}, true
+			}
+			return pdfToken{kind: 'o', str: word}, true
+		}
+	}
+	return pdfToken{}, false
+}
+
+// literal - строка в круглых скобках с учётом вложенных скобок и escape-последовательностей
+func (lx *pdfLexer) literal() []byte {
+	d := lx.data
+	lx.pos++
+	var out []byte
+	depth := 1
+	for lx.pos < len(d) {
+		c := d[lx.pos]
+		lx.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if lx.pos >= len(d) {
+				return out
+			}
+			e := d[lx.pos]
+			lx.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if lx.pos < len(d) && d[lx.pos] == '\n' {
+					lx.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for k := 0; k < 2 && lx.pos < len(d) && d[lx.pos] >= '0' && d[lx.pos] <= '7'; k++ {
+						v = v*8 + int(d[lx.pos]-'0')
+						lx.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// skipInlineImage - пропуск двоичных данных встроенного изображения между ID и EI
+func (lx *pdfLexer) skipInlineImage() {
+	d := lx.data
+	for i := lx.pos; i+2 < len(d); i++ {
+		if isPDFSpace(d[i]) && d[i+1] == 'E' && d[i+2] == 'I' && (i+3 == len(d) || isPDFSpace(d[i+3])) {
+			lx.pos = i + 3
+			return
+		}
+	}
+	lx.pos = len(d)
+}
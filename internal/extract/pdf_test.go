@@ -0,0 +1,52 @@
+package extract
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPDFDictValue(t *testing.T) {
+	dict := "<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 4 0 R >> >> /Kids [3 0 R 4 0 R] /Filter/FlateDecode >>"
+	assert.Equal(t, "2 0 R", pdfDictValue(dict, "Parent"))
+	assert.Equal(t, "<< /Font << /F1 4 0 R >> >>", pdfDictValue(dict, "Resources"))
+	assert.Equal(t, "[3 0 R 4 0 R]", pdfDictValue(dict, "Kids"))
+	assert.Equal(t, "/FlateDecode", pdfDictValue(dict, "Filter"))
+	assert.Equal(t, "/Page", pdfDictValue(dict, "Type"))
+	assert.Equal(t, "", pdfDictValue(dict, "Contents"))
+	assert.Equal(t, "", pdfDictValue("<< /ContentsX 5 0 R >>", "Contents"), "имя ключа должно совпадать целиком")
+
+	// Ключ в самом конце словаря без значения
+	assert.NotPanics(t, func() {
+		assert.Equal(t, "", pdfDictValue("<< /Type /Page /Contents ", "Contents"))
+		assert.Equal(t, "", pdfDictValue("/Contents\n", "Contents"))
+	})
+}
+
+func TestParsePDFObjectsMalformedObjStm(t *testing.T) {
+	objStm := func(header, body string) []byte {
+		stream := header + body
+		return []byte(fmt.Sprintf("%%PDF-1.5\n7 0 obj << /Type /ObjStm /N 2 /First %d /Length %d >>\nstream\n%s\nendstream\nendobj\n",
+			len(header), len(stream), stream))
+	}
+
+	t.Run("корректный", func(t *testing.T) {
+		objects := parsePDFObjects(objStm("8 0 9 11 ", "<< /A 1 >> << /B 2 >>"))
+		if assert.Contains(t, objects, 8) && assert.Contains(t, objects, 9) {
+			assert.Equal(t, "<< /A 1 >> ", objects[8].dict)
+			assert.Equal(t, "<< /B 2 >>", objects[9].dict)
+		}
+	})
+
+	for name, header := range map[string]string{
+		"отрицательное смещение":      "8 -94 9 0 ",
+		"отрицательное следующее":     "8 0 9 -5 ",
+		"смещение за концом потока":   "8 1000 9 2000 ",
+		"смещения в обратном порядке": "8 11 9 0 ",
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.NotPanics(t, func() { parsePDFObjects(objStm(header, "<< /A 1 >> << /B 2 >>")) })
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package extract
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"LestaStartTest/internal/charset"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// rtfSkipDestinations - служебные группы RTF, не содержащие текста документа
+var rtfSkipDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true, "pict": true,
+	"object": true, "themedata": true, "datastore": true, "xmlnstbl": true,
+	"listtable": true, "listoverridetable": true, "rsidtbl": true, "generator": true,
+	"latentstyles": true, "filetbl": true, "revtbl": true, "header": true, "footer": true,
+}
+
+// rtfCodepages - кодовые страницы из \ansicpgN, для которых есть декодер
+var rtfCodepages = map[int]encoding.Encoding{
+	866:   charmap.CodePage866,
+	1251:  charmap.Windows1251,
+	1252:  charmap.Windows1252,
+	20866: charmap.KOI8R,
+}
+
+type rtfState struct {
+	skip     bool
+	ucSkip   int // количество символов-заменителей после \uN
+	starDest bool
+}
+
+// extractRTF - разбор RTF: группы, управляющие слова, \'hh в кодовой странице документа и \uN
+func extractRTF(data []byte, _ string) (Result, error) {
+	src := string(data)
+	codepage := encoding.Encoding(charmap.Windows1252)
+
+	var (
+		out     strings.Builder
+		pending []byte // байты из \'hh, ожидающие декодирования
+		stack   []rtfState
+		state   = rtfState{ucSkip: 1}
+		toSkip  int // оставшиеся символы-заменители после \uN
+	)
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if decoded, err := codepage.NewDecoder().Bytes(pending); err == nil {
+			out.Write(decoded)
+		}
+		pending = pending[:0]
+	}
+	emit := func(s string) {
+		if state.skip {
+			return
+		}
+		if toSkip > 0 {
+			toSkip--
+			return
+		}
+		flush()
+		out.WriteString(s)
+	}
+
+	for i := 0; i < len(src); i++ {
+		ch := src[i]
+		switch ch {
+		case '{':
+			stack = append(stack, state)
+			state.starDest = false
+		case '}':
+			if len(stack) == 0 {
+				return Result{}, errors.New("malformed rtf: unbalanced braces")
+			}
+			flush()
+			state = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		case '\r', '\n':
+		case '\\':
+			if i+1 >= len(src) {
+				break
+			}
+			next := src[i+1]
+			switch {
+			case next == '\'' && i+3 < len(src):
+				if b, err := strconv.ParseUint(src[i+2:i+4], 16, 8); err == nil && !state.skip {
+					if toSkip > 0 {
+						toSkip--
+					} else {
+						pending = append(pending, byte(b))
+					}
+				}
+				i += 3
+			case next == '*':
+				state.starDest = true
+				i++
+			case next == '\\' || next == '{' || next == '}':
+				emit(string(next))
+				i++
+			case next == '~':
+				emit(" ")
+				i++
+			case isRTFLetter(next):
+				j := i + 1
+				for j < len(src) && isRTFLetter(src[j]) {
+					j++
+				}
+				word := src[i+1 : j]
+				k := j
+				if k < len(src) && (src[k] == '-' || isDigit(src[k])) {
+					k++
+					for k < len(src) && isDigit(src[k]) {
+						k++
+					}
+				}
+				param, hasParam := 0, k > j
+				if hasParam {
+					param, _ = strconv.Atoi(src[j:k])
+				}
+				if k < len(src) && src[k] == ' ' {
+					k++
+				}
+				i = k - 1
+
+				switch {
+				case rtfSkipDestinations[word] || state.starDest:
+					state.skip = true
+				case word == "ansicpg" && hasParam:
+					if enc, ok := rtfCodepages[param]; ok {
+						codepage = enc
+					}
+				case word == "uc" && hasParam:
+					state.ucSkip = param
+				case word == "u" && hasParam:
+					if param < 0 {
+						param += 65536
+					}
+					emit(string(rune(param)))
+					if !state.skip {
+						toSkip = state.ucSkip
+					}
+				case word == "par" || word == "line" || word == "sect" || word == "page" || word == "row":
+					emit("\n")
+				case word == "tab" || word == "cell":
+					emit("\t")
+				case word == "emdash":
+					emit("—")
+				case word == "endash":
+					emit("–")
+				case word == "lquote" || word == "rquote":
+					emit("'")
+				case word == "ldblquote" || word == "rdblquote":
+					emit("\"")
+				}
+			default:
+				i++
+			}
+		default:
+			if ch < 0x80 {
+				emit(string(ch))
+			} else if !state.skip {
+				// 8-битные символы вне \'hh встречаются у некоторых редакторов, считаем их байтами кодовой страницы
+				pending = append(pending, ch)
+			}
+		}
+	}
+	flush()
+
+	return Result{Text: strings.TrimSpace(out.String()), Encoding: charset.UTF8}, nil
+}
+
+func isRTFLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"LestaStartTest/internal/models"
+)
+
+// Типы событий задачи
+const (
+	EventProgress  = "progress"
+	EventCompleted = "completed"
+	EventFailed    = "failed"
+)
+
+// Этапы выполнения задачи
+const (
+	StageFiles = "files" // обработка загруженных файлов
+	StageIDF   = "idf"   // пересчёт IDF коллекции
+)
+
+// Минимальный интервал между событиями прогресса одной задачи, кроме последнего шага этапа
+const progressInterval = 100 * time.Millisecond
+
+// Event - событие выполнения задачи для подписчиков (SSE)
+type Event struct {
+	Type         string `json:"type" enums:"progress,completed,failed"`
+	JobID        uint   `json:"job_id"`
+	Stage        string `json:"stage,omitempty" enums:"files,idf"`
+	Processed    int    `json:"processed"`
+	Total        int    `json:"total"`
+	Item         string `json:"item,omitempty"`          // обработанный файл
+	CollectionID uint   `json:"collection_id,omitempty"` // коллекция, IDF которой пересчитывается
+	Error        string `json:"error,omitempty"`
+}
+
+var (
+	subscribersMu sync.Mutex
+	subscribers   = make(map[uint]map[chan Event]struct{})
+)
+
+// Subscribe - подписка на события задачи в этом экземпляре приложения. cancel обязательно вызывать.
+// Медленный подписчик пропускает события, поэтому итоговый статус нужно дополнительно проверять в БД.
+func Subscribe(jobID uint) (events <-chan Event, cancel func()) {
+	ch := make(chan Event, 64)
+
+	subscribersMu.Lock()
+	if subscribers[jobID] == nil {
+		subscribers[jobID] = make(map[chan Event]struct{})
+	}
+	subscribers[jobID][ch] = struct{}{}
+	subscribersMu.Unlock()
+
+	return ch, func() {
+		subscribersMu.Lock()
+		delete(subscribers[jobID], ch)
+		if len(subscribers[jobID]) == 0 {
+			delete(subscribers, jobID)
+		}
+		subscribersMu.Unlock()
+	}
+}
+
+func publish(event Event) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	for ch := range subscribers[event.JobID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// EventFor - событие, описывающее текущее состояние задачи из БД
+func EventFor(job models.Job) Event {
+	event := Event{Type: EventProgress, JobID: job.ID, Stage: StageFiles, Processed: job.Processed, Total: job.Total}
+	switch job.Status {
+	case StatusSucceeded:
+		event.Type, event.Stage = EventCompleted, ""
+	case StatusFailed:
+		event.Type, event.Stage, event.Error = EventFailed, "", job.Error
+	}
+	return event
+}
+
+type runKey struct{}
+
+// WithRun - контекст, через который вложенные функции сообщают о прогрессе задачи
+func WithRun(ctx context.Context, run *Run) context.Context {
+	return context.WithValue(ctx, runKey{}, run)
+}
+
+// FromContext - выполняемая задача из контекста или nil, если код вызван не из задачи
+func FromContext(ctx context.Context) *Run {
+	run, _ := ctx.Value(runKey{}).(*Run)
+	return run
+}
+
+// Progress - событие прогресса этапа задачи. События чаще progressInterval пропускаются,
+// кроме завершения этапа. У nil-задачи ничего не делает.
+func (r *Run) Progress(event Event) {
+	if r == nil {
+		return
+	}
+	r.eventsMu.Lock()
+	now := time.Now()
+	if event.Processed < event.Total && now.Sub(r.lastEvent) < progressInterval {
+		r.eventsMu.Unlock()
+		return
+	}
+	r.lastEvent = now
+	r.eventsMu.Unlock()
+
+	event.Type = EventProgress
+	event.JobID = r.Job.ID
+	publish(event)
+}
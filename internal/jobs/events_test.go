@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+
+	"LestaStartTest/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribe(t *testing.T) {
+	events, cancel := Subscribe(1)
+	other, cancelOther := Subscribe(2)
+	defer cancelOther()
+
+	publish(Event{Type: EventProgress, JobID: 1, Processed: 1, Total: 2})
+	assert.Equal(t, Event{Type: EventProgress, JobID: 1, Processed: 1, Total: 2}, <-events)
+	assert.Empty(t, other, "события другой задачи не приходят")
+
+	cancel()
+	publish(Event{Type: EventCompleted, JobID: 1})
+	assert.Empty(t, events, "после отписки события не приходят")
+	assert.NotContains(t, subscribers, uint(1))
+}
+
+func TestPublishDoesNotBlock(t *testing.T) {
+	events, cancel := Subscribe(3)
+	defer cancel()
+	for i := 0; i < 100; i++ {
+		publish(Event{Type: EventProgress, JobID: 3, Processed: i})
+	}
+	assert.Len(t, events, cap(events), "лишние события медленного подписчика отбрасываются")
+}
+
+func TestProgressThrottle(t *testing.T) {
+	run := &Run{Job: &models.Job{ID: 4}}
+	events, cancel := Subscribe(4)
+	defer cancel()
+
+	run.Progress(Event{Stage: StageIDF, Processed: 1, Total: 3})
+	run.Progress(Event{Stage: StageIDF, Processed: 2, Total: 3})
+	run.Progress(Event{Stage: StageIDF, Processed: 3, Total: 3})
+
+	assert.Len(t, events, 2, "промежуточное событие пропускается, завершение этапа - нет")
+	first := <-events
+	assert.Equal(t, EventProgress, first.Type)
+	assert.Equal(t, uint(4), first.JobID)
+	assert.Equal(t, 3, (<-events).Processed)
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Nil(t, FromContext(context.Background()))
+	FromContext(context.Background()).Progress(Event{}) // вне задачи ничего не делает
+
+	run := &Run{Job: &models.Job{ID: 5}}
+	assert.Same(t, run, FromContext(WithRun(context.Background(), run)))
+}
+
+func TestEventFor(t *testing.T) {
+	assert.Equal(t, Event{Type: EventProgress, JobID: 1, Stage: StageFiles, Processed: 1, Total: 3},
+		EventFor(models.Job{ID: 1, Status: StatusRunning, Processed: 1, Total: 3}))
+	assert.Equal(t, EventCompleted, EventFor(models.Job{Status: StatusSucceeded}).Type)
+	failed := EventFor(models.Job{Status: StatusFailed, Error: "boom"})
+	assert.Equal(t, EventFailed, failed.Type)
+	assert.Equal(t, "boom", failed.Error)
+}
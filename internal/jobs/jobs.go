@@ -0,0 +1,332 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Статусы задач
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+const (
+	defaultWorkers    = 4
+	defaultRetention  = 7 * 24 * time.Hour
+	maxAttempts       = 3
+	pollInterval      = 5 * time.Second
+	heartbeatInterval = 30 * time.Second
+	staleAfter        = 2 * time.Minute // задача без heartbeat дольше этого времени считается прерванной
+)
+
+// Handler - обработчик задачи определённого типа. Результат сохраняется в Job.Result в JSON.
+type Handler func(ctx context.Context, run *Run) (any, error)
+
+var (
+	handlers = make(map[string]Handler)
+	wake     = make(chan struct{}, 64)
+)
+
+// Register - регистрация обработчика для типа задачи. Вызывается до Start.
+func Register(jobType string, handler Handler) {
+	handlers[jobType] = handler
+}
+
+// Create - сохранение новой задачи с входными параметрами payload и пробуждение обработчиков
+func Create(job *models.Job, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	job.Payload = string(data)
+	job.Status = StatusQueued
+	if err := db.DB.Create(job).Error; err != nil {
+		return err
+	}
+	notify()
+	return nil
+}
+
+// NewFileKey - ключ временного файла задачи в хранилище
+func NewFileKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "jobs/" + hex.EncodeToString(b)
+}
+
+// Start - запуск JOB_WORKERS обработчиков (по умолчанию 4) и фонового обслуживания очереди:
+// возврат прерванных задач в очередь и удаление завершённых задач старше JOB_RETENTION
+func Start(ctx context.Context) {
+	workers := defaultWorkers
+	if v, err := strconv.Atoi(os.Getenv("JOB_WORKERS")); err == nil && v > 0 {
+		workers = v
+	}
+	retention := defaultRetention
+	if v, err := time.ParseDuration(os.Getenv("JOB_RETENTION")); err == nil && v > 0 {
+		retention = v
+	}
+
+	for i := 0; i < workers; i++ {
+		go worker(ctx)
+	}
+	go maintain(ctx, retention)
+}
+
+// Run - выполняемая задача; через неё обработчик читает параметры и сообщает о прогрессе
+type Run struct {
+	Job *models.Job
+	mu  sync.Mutex
+
+	eventsMu  sync.Mutex
+	lastEvent time.Time // время последнего события прогресса
+}
+
+// Decode - чтение входных параметров задачи
+func (r *Run) Decode(v any) error {
+	return json.Unmarshal([]byte(r.Job.Payload), v)
+}
+
+// SetTotal - количество шагов задачи
+func (r *Run) SetTotal(total int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Job.Total = total
+	r.save("total")
+}
+
+// Step - завершение обработки файла item. Ошибка добавляется в список ошибок задачи.
+func (r *Run) Step(item string, stepErr error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Job.Processed++
+	event := Event{Type: EventProgress, JobID: r.Job.ID, Stage: StageFiles, Processed: r.Job.Processed, Total: r.Job.Total, Item: item}
+	if stepErr != nil {
+		r.Job.Errors = append(r.Job.Errors, stepErr.Error())
+		event.Error = stepErr.Error()
+	}
+	r.save("processed", "errors")
+	publish(event)
+}
+
+// AddError - ошибка, не связанная с отдельным шагом (например, при сохранении результата)
+func (r *Run) AddError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Job.Errors = append(r.Job.Errors, err.Error())
+	r.save("errors")
+}
+
+// Checkpoint - сохранение состояния обработчика в транзакции tx, в которой он записывает свои данные.
+// Если задача прервётся после фиксации tx, повторный запуск не сбрасывает прогресс и ошибки,
+// а обработчик получает состояние через Restore и не повторяет уже записанное.
+func (r *Run) Checkpoint(tx *gorm.DB, state any) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Job.Checkpoint = string(data)
+	return tx.Model(r.Job).Select("checkpoint", "processed", "errors").Updates(r.Job).Error
+}
+
+// Restore - чтение состояния, сохранённого Checkpoint при прошлом запуске; false, если его нет
+func (r *Run) Restore(v any) (bool, error) {
+	if r.Job.Checkpoint == "" {
+		return false, nil
+	}
+	return true, json.Unmarshal([]byte(r.Job.Checkpoint), v)
+}
+
+func (r *Run) save(columns ...string) {
+	if err := db.DB.Model(r.Job).Select(columns).Updates(r.Job).Error; err != nil {
+		log.Printf("Failed to save job %d progress: %v", r.Job.ID, err)
+	}
+}
+
+func worker(ctx context.Context) {
+	for {
+		job, err := claim()
+		if err != nil {
+			log.Printf("Failed to claim job: %v", err)
+		}
+		if job != nil {
+			execute(ctx, job)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// claim - захват самой старой задачи из очереди. Задачу может одновременно пытаться забрать
+// другой обработчик или другая реплика, поэтому статус меняется только если он ещё queued.
+func claim() (*models.Job, error) {
+	for {
+		var job models.Job
+		err := db.DB.Where("status = ?", StatusQueued).Order("id").First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Повторный запуск начинается с начала, если обработчик не успел сохранить состояние (см. Run.Checkpoint)
+		job.Status = StatusRunning
+		job.Attempts++
+		if job.Checkpoint == "" {
+			job.Processed = 0
+			job.Errors = nil
+		}
+		res := db.DB.Model(&job).Where("status = ?", StatusQueued).
+			Select("status", "attempts", "processed", "errors").
+			Updates(&job)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return &job, nil
+		}
+	}
+}
+
+func execute(ctx context.Context, job *models.Job) {
+	run := &Run{Job: job}
+
+	beatCtx, stop := context.WithCancel(ctx)
+	go heartbeat(beatCtx, job.ID)
+	result, err := safeRun(WithRun(ctx, run), run)
+	stop()
+
+	// При остановке приложения задача остаётся running и после перезапуска возвращается в очередь
+	if ctx.Err() != nil {
+		return
+	}
+	finish(run, result, err)
+}
+
+func safeRun(ctx context.Context, run *Run) (result any, err error) {
+	handler, ok := handlers[run.Job.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown job type %q", run.Job.Type)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return handler(ctx, run)
+}
+
+func finish(run *Run, result any, err error) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	job := run.Job
+	now := time.Now()
+	job.FinishedAt = &now
+	if err != nil {
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = StatusSucceeded
+		data, _ := json.Marshal(result)
+		job.Result = string(data)
+	}
+	if err := db.DB.Model(job).
+		Select("status", "error", "result", "finished_at", "processed", "errors").
+		Updates(job).Error; err != nil {
+		log.Printf("Failed to finish job %d: %v", job.ID, err)
+	}
+	db.RemoveBlobFiles(context.Background(), job.Files)
+	publish(EventFor(*job))
+}
+
+// heartbeat - периодическое обновление updated_at, пока задача выполняется
+func heartbeat(ctx context.Context, jobID uint) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			db.DB.Model(&models.Job{}).Where("id = ?", jobID).Update("updated_at", time.Now())
+		}
+	}
+}
+
+// maintain - возврат в очередь задач, выполнение которых прервалось (например, перезапуском),
+// и удаление старых завершённых задач
+func maintain(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		if err := requeueStale(); err != nil {
+			log.Printf("Failed to requeue stale jobs: %v", err)
+		}
+		if err := db.DB.Where("finished_at < ?", time.Now().Add(-retention)).
+			Delete(&models.Job{}).Error; err != nil {
+			log.Printf("Failed to delete old jobs: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func requeueStale() error {
+	var stale []models.Job
+	if err := db.DB.Where("status = ? AND updated_at < ?", StatusRunning, time.Now().Add(-staleAfter)).
+		Find(&stale).Error; err != nil {
+		return err
+	}
+	for i := range stale {
+		job := &stale[i]
+		if job.Attempts >= maxAttempts {
+			finish(&Run{Job: job}, nil, fmt.Errorf("job interrupted %d times", job.Attempts))
+			continue
+		}
+		res := db.DB.Model(job).Where("status = ?", StatusRunning).Update("status", StatusQueued)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 1 {
+			log.Printf("Job %d interrupted, returned to queue", job.ID)
+			notify()
+		}
+	}
+	return nil
+}
+
+// notify - пробуждение свободного обработчика без ожидания
+func notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
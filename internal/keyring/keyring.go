@@ -0,0 +1,238 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Минимальная длина секрета HS256 и ключа RSA
+const (
+	MinSecretLength = 32
+	MinRSABits      = 2048
+)
+
+var (
+	ErrUnknownKey     = errors.New("unknown signing key")
+	ErrUnsupportedKey = errors.New("unsupported key type")
+	ErrNoSigningKey   = errors.New("no signing key")
+	ErrWeakKey        = errors.New("key is too weak")
+)
+
+// Key - ключ подписи токенов. У ключа, выведенного из обращения, остаётся только открытая часть:
+// он проверяет ранее выданные токены, но не подписывает новые.
+type Key struct {
+	ID        string // kid в заголовке токена
+	Method    jwt.SigningMethod
+	SignKey   interface{} // nil - ключ только для проверки
+	VerifyKey interface{}
+}
+
+// CanSign - есть ли у ключа закрытая часть
+func (k *Key) CanSign() bool {
+	return k.SignKey != nil
+}
+
+// NewHMAC - симметричный ключ HS256. Такой ключ не публикуется в JWKS.
+func NewHMAC(id string, secret []byte) (*Key, error) {
+	if len(secret) < MinSecretLength {
+		return nil, fmt.Errorf("%w: HMAC secret must be at least %d bytes", ErrWeakKey, MinSecretLength)
+	}
+	return &Key{ID: id, Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret}, nil
+}
+
+// ParsePEM - ключ из PEM: закрытый (PKCS#8 или PKCS#1) или открытый (PKIX). Алгоритм определяется типом ключа:
+// Ed25519 - EdDSA, RSA - RS256.
+func ParsePEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s: no PEM data", id)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %s: %w: PEM block %q", id, ErrUnsupportedKey, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", id, err)
+	}
+
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, SignKey: k, VerifyKey: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, VerifyKey: k}, nil
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < MinRSABits {
+			return nil, fmt.Errorf("key %s: %w: RSA key must be at least %d bits", id, ErrWeakKey, MinRSABits)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, SignKey: k, VerifyKey: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < MinRSABits {
+			return nil, fmt.Errorf("key %s: %w: RSA key must be at least %d bits", id, ErrWeakKey, MinRSABits)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, VerifyKey: k}, nil
+	default:
+		return nil, fmt.Errorf("key %s: %w: %T", id, ErrUnsupportedKey, parsed)
+	}
+}
+
+// Keyring - ключ, которым подписываются новые токены, и ключи, которыми ещё проверяются выданные ранее
+type Keyring struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+// New - набор ключей; active подписывает новые токены и должен иметь закрытую часть
+func New(active *Key, others ...*Key) (*Keyring, error) {
+	if active == nil || !active.CanSign() {
+		return nil, ErrNoSigningKey
+	}
+	kr := &Keyring{active: active, keys: map[string]*Key{active.ID: active}}
+	for _, key := range others {
+		if _, ok := kr.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		kr.keys[key.ID] = key
+	}
+	return kr, nil
+}
+
+// LoadDir - ключи из файлов *.pem каталога; kid - имя файла без расширения.
+// Новые токены подписывает ключ activeID, остальные только проверяют выданные ими токены.
+func LoadDir(dir, activeID string) (*Keyring, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var active *Key
+	var others []*Key
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParsePEM(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, err
+		}
+		if key.ID == activeID {
+			active = key
+		} else {
+			others = append(others, key)
+		}
+	}
+	if active == nil {
+		return nil, fmt.Errorf("%w: key %q not found in %s", ErrNoSigningKey, activeID, dir)
+	}
+	return New(active, others...)
+}
+
+// Active - ключ для подписи новых токенов
+func (kr *Keyring) Active() *Key {
+	return kr.active
+}
+
+// Lookup - ключ проверки по kid из заголовка токена
+func (kr *Keyring) Lookup(kid string) (*Key, error) {
+	key, ok := kr.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// Methods - алгоритмы всех ключей набора; токены с другим alg отклоняются
+func (kr *Keyring) Methods() []string {
+	var methods []string
+	for _, key := range kr.keys {
+		if alg := key.Method.Alg(); !slices.Contains(methods, alg) {
+			methods = append(methods, alg)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Keyfunc - выбор ключа проверки по kid. Алгоритм токена должен совпадать с алгоритмом ключа,
+// иначе открытый ключ RSA можно было бы выдать за секрет HMAC.
+func (kr *Keyring) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, err := kr.Lookup(kid)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("%w: alg %s does not match key %q", jwt.ErrTokenSignatureInvalid, token.Method.Alg(), kid)
+	}
+	return key.VerifyKey, nil
+}
+
+// Sign - подпись claims активным ключом
+func (kr *Keyring) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(kr.active.Method, claims)
+	if kr.active.ID != "" {
+		token.Header["kid"] = kr.active.ID
+	}
+	return token.SignedString(kr.active.SignKey)
+}
+
+// JWK - открытый ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"` // OKP
+	X   string `json:"x,omitempty"`   // OKP
+	N   string `json:"n,omitempty"`   // RSA
+	E   string `json:"e,omitempty"`   // RSA
+}
+
+// JWKSet - набор открытых ключей для /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS - открытые ключи набора; симметричные ключи не публикуются
+func (kr *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range kr.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.VerifyKey.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
@@ -0,0 +1,167 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKey(t *testing.T, dir, name string, key interface{}, public bool) {
+	t.Helper()
+	var block *pem.Block
+	if public {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	} else {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(block), 0o600))
+}
+
+func parse(kr *Keyring, token string) error {
+	_, err := jwt.Parse(token, kr.Keyfunc, jwt.WithValidMethods(kr.Methods()))
+	return err
+}
+
+func claims() jwt.Claims {
+	return jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}
+}
+
+func TestLoadDirRotation(t *testing.T) {
+	dir := t.TempDir()
+	oldPub, oldPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, newPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writeKey(t, dir, "2025-01", oldPriv, false)
+	writeKey(t, dir, "2025-02", newPriv, false)
+
+	before, err := LoadDir(dir, "2025-01")
+	require.NoError(t, err)
+	oldToken, err := before.Sign(claims())
+	require.NoError(t, err)
+
+	// Новый ключ подписывает, старый выведен из обращения: осталась только открытая часть
+	writeKey(t, dir, "2025-01", oldPub, true)
+	after, err := LoadDir(dir, "2025-02")
+	require.NoError(t, err)
+	assert.Equal(t, "2025-02", after.Active().ID)
+	assert.False(t, mustLookup(t, after, "2025-01").CanSign())
+
+	newToken, err := after.Sign(claims())
+	require.NoError(t, err)
+	assert.NoError(t, parse(after, oldToken))
+	assert.NoError(t, parse(after, newToken))
+
+	// Ключ, у которого осталась только открытая часть, не может подписывать
+	_, err = LoadDir(dir, "2025-01")
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	// После удаления старого ключа его токены больше не принимаются
+	require.NoError(t, os.Remove(filepath.Join(dir, "2025-01.pem")))
+	after, err = LoadDir(dir, "2025-02")
+	require.NoError(t, err)
+	assert.ErrorIs(t, parse(after, oldToken), ErrUnknownKey)
+	assert.NoError(t, parse(after, newToken))
+	_, err = LoadDir(dir, "missing")
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+}
+
+func mustLookup(t *testing.T, kr *Keyring, kid string) *Key {
+	t.Helper()
+	key, err := kr.Lookup(kid)
+	require.NoError(t, err)
+	return key
+}
+
+func TestAlgorithmPinning(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	writeKey(t, dir, "rsa", priv, false)
+	kr, err := LoadDir(dir, "rsa")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"RS256"}, kr.Methods())
+
+	// Токен HS256, подписанный открытым ключом RSA как секретом, отклоняется
+	pubDER, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	forged.Header["kid"] = "rsa"
+	token, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	require.NoError(t, err)
+	assert.Error(t, parse(kr, token))
+
+	// Неизвестный kid
+	unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, claims())
+	unknown.Header["kid"] = "other"
+	token, err = unknown.SignedString(priv)
+	require.NoError(t, err)
+	assert.ErrorIs(t, parse(kr, token), ErrUnknownKey)
+
+	// Алгоритм none
+	token, err = jwt.NewWithClaims(jwt.SigningMethodNone, claims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	assert.Error(t, parse(kr, token))
+}
+
+func TestHMAC(t *testing.T) {
+	_, err := NewHMAC("", []byte("short"))
+	assert.ErrorIs(t, err, ErrWeakKey)
+
+	key, err := NewHMAC("", []byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	kr, err := New(key)
+	require.NoError(t, err)
+	token, err := kr.Sign(claims())
+	require.NoError(t, err)
+	assert.NoError(t, parse(kr, token))
+	assert.Empty(t, kr.JWKS().Keys)
+}
+
+func TestJWKS(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	writeKey(t, dir, "ed", priv, false)
+	writeKey(t, dir, "rsa", &rsaKey.PublicKey, true)
+	kr, err := LoadDir(dir, "ed")
+	require.NoError(t, err)
+
+	keys := kr.JWKS().Keys
+	require.Len(t, keys, 2)
+	assert.Equal(t, JWK{Kty: "OKP", Kid: "ed", Use: "sig", Alg: "EdDSA", Crv: "Ed25519",
+		X: jwtEncode(pub)}, keys[0])
+	assert.Equal(t, "RSA", keys[1].Kty)
+	assert.Equal(t, "RS256", keys[1].Alg)
+	assert.Equal(t, "AQAB", keys[1].E)
+	assert.Equal(t, jwtEncode(rsaKey.N.Bytes()), keys[1].N)
+}
+
+func TestParsePEMWeakRSA(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)})
+	_, err = ParsePEM("weak", data)
+	assert.ErrorIs(t, err, ErrWeakKey)
+}
+
+func jwtEncode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,114 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ограничения пользовательских метаданных документа
+const (
+	MaxTags        = 50   // тегов у одного документа
+	MaxTagLength   = 64   // символов в теге
+	MaxFields      = 50   // дополнительных полей у одного документа
+	MaxKeyLength   = 64   // символов в имени поля
+	MaxValueLength = 1024 // символов в значении поля, заголовке и авторе
+)
+
+var (
+	ErrTooManyTags   = fmt.Errorf("too many tags (maximum %d)", MaxTags)
+	ErrTooManyFields = fmt.Errorf("too many fields (maximum %d)", MaxFields)
+	ErrEmptyKey      = errors.New("field name must not be empty")
+	ErrInvalidURL    = errors.New("source_url must be an absolute http or https URL")
+)
+
+// NormalizeTag - тег без пробелов по краям и в нижнем регистре: "Отчёт " и "отчёт" - один тег
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", errors.New("tag must not be empty")
+	}
+	if strings.ContainsAny(tag, ",\n\r\t") {
+		return "", fmt.Errorf("tag %q must not contain commas or line breaks", tag)
+	}
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", fmt.Errorf("tag %q is longer than %d characters", tag, MaxTagLength)
+	}
+	return tag, nil
+}
+
+// NormalizeTags - нормализованные теги без повторов в порядке сортировки
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	if len(result) > MaxTags {
+		return nil, ErrTooManyTags
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// MergeFields - применение изменений к дополнительным полям: nil-значение удаляет поле, остальные задают его
+func MergeFields(fields map[string]string, patch map[string]*string) (map[string]string, error) {
+	result := make(map[string]string, len(fields)+len(patch))
+	for key, value := range fields {
+		result[key] = value
+	}
+	for key, value := range patch {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, ErrEmptyKey
+		}
+		if utf8.RuneCountInString(key) > MaxKeyLength {
+			return nil, fmt.Errorf("field name %q is longer than %d characters", key, MaxKeyLength)
+		}
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		if err := CheckValue(key, *value); err != nil {
+			return nil, err
+		}
+		result[key] = *value
+	}
+	if len(result) > MaxFields {
+		return nil, ErrTooManyFields
+	}
+	return result, nil
+}
+
+// CheckValue - проверка длины текстового значения метаданных
+func CheckValue(name, value string) error {
+	if utf8.RuneCountInString(value) > MaxValueLength {
+		return fmt.Errorf("%s is longer than %d characters", name, MaxValueLength)
+	}
+	return nil
+}
+
+// CheckURL - адрес источника должен быть абсолютным http(s) URL; пустая строка очищает его
+func CheckURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	if err := CheckValue("source_url", raw); err != nil {
+		return err
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	return nil
+}
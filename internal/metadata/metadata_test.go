@@ -0,0 +1,58 @@
+package metadata
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{" Отчёт", "отчёт", "2024", "Draft "})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024", "draft", "отчёт"}, tags)
+
+	tags, err = NormalizeTags(nil)
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	_, err = NormalizeTags([]string{"  "})
+	assert.Error(t, err)
+	_, err = NormalizeTags([]string{"a,b"})
+	assert.Error(t, err)
+	_, err = NormalizeTags([]string{strings.Repeat("я", MaxTagLength+1)})
+	assert.Error(t, err)
+
+	many := make([]string, MaxTags+1)
+	for i := range many {
+		many[i] = strings.Repeat("t", i+1)
+	}
+	_, err = NormalizeTags(many)
+	assert.ErrorIs(t, err, ErrTooManyTags)
+}
+
+func TestMergeFields(t *testing.T) {
+	value := "Иванов"
+	fields, err := MergeFields(map[string]string{"project": "x", "owner": "a"}, map[string]*string{"owner": &value, "project": nil})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "Иванов"}, fields)
+
+	fields, err = MergeFields(nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	_, err = MergeFields(nil, map[string]*string{" ": &value})
+	assert.ErrorIs(t, err, ErrEmptyKey)
+	long := strings.Repeat("a", MaxValueLength+1)
+	_, err = MergeFields(nil, map[string]*string{"k": &long})
+	assert.Error(t, err)
+}
+
+func TestCheckURL(t *testing.T) {
+	assert.NoError(t, CheckURL(""))
+	assert.NoError(t, CheckURL("https://example.com/report.pdf"))
+	assert.ErrorIs(t, CheckURL("ftp://example.com/a"), ErrInvalidURL)
+	assert.ErrorIs(t, CheckURL("/relative/path"), ErrInvalidURL)
+	assert.ErrorIs(t, CheckURL("http://"), ErrInvalidURL)
+}
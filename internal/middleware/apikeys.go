@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Права API-ключей: read - только чтение, write - чтение и изменение данных,
+// admin - дополнительно управление аккаунтом (пароль, сессии, API-ключи)
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// APIKeyPrefix - начало каждого API-ключа, по нему ключ легко найти в логах и конфигурации
+const APIKeyPrefix = "lsk_"
+
+var ErrInvalidAPIKey = errors.New("invalid or expired api key")
+
+// HashAPIKey - SHA-256 ключа; в БД хранится только он
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyFromRequest - ключ из "Authorization: ApiKey ..." или X-API-Key
+func apiKeyFromRequest(c *gin.Context) (string, bool) {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key, true
+	}
+	scheme, key, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if ok && scheme == "ApiKey" {
+		return key, true
+	}
+	return "", false
+}
+
+// apiKeyAuth - проверка API-ключа и его прав на метод запроса
+func apiKeyAuth(c *gin.Context, key string) {
+	var apiKey models.APIKey
+	err := db.DB.Where("key_hash = ?", HashAPIKey(key)).First(&apiKey).Error
+	if err == nil && apiKey.ExpiresAt.Before(time.Now()) {
+		err = ErrInvalidAPIKey
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, ErrInvalidAPIKey) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired API key"})
+		return
+	} else if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var user models.User
+	if err := db.DB.Select("role", "disabled").First(&user, apiKey.UserID).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired API key"})
+		return
+	}
+	if user.Disabled {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		return
+	}
+
+	if apiKey.Scope == ScopeRead && !isSafeMethod(c.Request.Method) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key scope does not allow changes"})
+		return
+	}
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > sessionTouchInterval {
+		if err := db.DB.Model(&apiKey).Update("last_used_at", time.Now()).Error; err != nil {
+			log.Printf("Failed to update API key %d: %v", apiKey.ID, err)
+		}
+	}
+
+	c.Set("userID", apiKey.UserID)
+	c.Set("claims", &Claims{UserID: apiKey.UserID})
+	c.Set("scope", apiKey.Scope)
+	c.Set("role", user.Role)
+	c.Next()
+}
+
+// RequireScope - доступ к маршруту для API-ключей не ниже scope. Запросы с JWT проходят без ограничений.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if granted := c.GetString("scope"); granted != "" && scopeRank(granted) < scopeRank(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key scope " + granted + " is not enough, " + scope + " required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func scopeRank(scope string) int {
+	switch scope {
+	case ScopeRead:
+		return 1
+	case ScopeWrite:
+		return 2
+	case ScopeAdmin:
+		return 3
+	}
+	return 0
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
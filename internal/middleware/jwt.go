@@ -1,38 +1,128 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"LestaStartTest/internal/keyring"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtKey = []byte(os.Getenv("JWT_SECRET"))
+// Срок действия access-токена по умолчанию. Долгую сессию продлевает refresh-токен.
+const defaultAccessTokenTTL = 15 * time.Minute
+
+// Издатель и получатель токенов по умолчанию
+const defaultTokenIssuer = "LestaStartTest"
+
+// keys - ключи подписи; загружаются в InitKeys после чтения .env
+var keys *keyring.Keyring
 
 type Claims struct {
-	UserID uint `json:"user_id"`
-	jwt.StandardClaims
+	UserID    uint   `json:"user_id"`
+	SessionID string `json:"sid,omitempty"` // сессия, к которой относится токен
+	jwt.RegisteredClaims
+}
+
+// InitKeys - загрузка ключей подписи. Если задан JWT_KEYS_DIR, токены подписываются ключом JWT_ACTIVE_KEY
+// из этого каталога (EdDSA или RS256), остальные ключи каталога только проверяют выданные ранее токены.
+// Иначе используется HS256 с секретом JWT_SECRET.
+func InitKeys() {
+	var err error
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		keys, err = keyring.LoadDir(dir, os.Getenv("JWT_ACTIVE_KEY"))
+	} else {
+		var key *keyring.Key
+		if key, err = keyring.NewHMAC("", []byte(os.Getenv("JWT_SECRET"))); err == nil {
+			keys, err = keyring.New(key)
+		}
+	}
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+}
+
+// JWKS - открытые ключи для проверки токенов другими сервисами
+func JWKS() keyring.JWKSet {
+	return keys.JWKS()
+}
+
+// AccessTokenTTL - срок действия access-токена из ACCESS_TOKEN_TTL (по умолчанию 15 минут)
+func AccessTokenTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_TTL")); err == nil && d > 0 {
+		return d
+	}
+	return defaultAccessTokenTTL
+}
+
+// tokenIssuer - значение iss из JWT_ISSUER
+func tokenIssuer() string {
+	if v := os.Getenv("JWT_ISSUER"); v != "" {
+		return v
+	}
+	return defaultTokenIssuer
+}
+
+// tokenAudience - значение aud из JWT_AUDIENCE
+func tokenAudience() string {
+	if v := os.Getenv("JWT_AUDIENCE"); v != "" {
+		return v
+	}
+	return defaultTokenIssuer
 }
 
-// GenerateToken - создание JWT при логине и регистрации
-func GenerateToken(userID uint) (string, error) {
-	expiration := time.Now().Add(24 * time.Hour)
+// GenerateToken - создание access-токена сессии. Уникальный jti позволяет отозвать отдельный токен.
+func GenerateToken(userID uint, sessionID string) (string, error) {
+	now := time.Now()
+	id := make([]byte, 16)
+	rand.Read(id)
 	claims := &Claims{
-		UserID: userID,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expiration.Unix(),
+		UserID:    userID,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(id),
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Issuer:    tokenIssuer(),
+			Audience:  jwt.ClaimStrings{tokenAudience()},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+	return keys.Sign(claims)
 }
 
-// JWTAuth - проверка JWT из заголовка Authorization
+// ParseToken - проверка подписи и claims access-токена. Принимаются только алгоритмы ключей из набора,
+// iss и aud должны совпадать с JWT_ISSUER и JWT_AUDIENCE.
+func ParseToken(tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, keys.Keyfunc,
+		jwt.WithValidMethods(keys.Methods()),
+		jwt.WithIssuer(tokenIssuer()),
+		jwt.WithAudience(tokenAudience()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// JWTAuth - проверка JWT из заголовка Authorization или API-ключа ("Authorization: ApiKey ..." либо X-API-Key)
 func JWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key, ok := apiKeyFromRequest(c); ok {
+			apiKeyAuth(c, key)
+			return
+		}
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing token"})
@@ -43,16 +133,39 @@ func JWTAuth() gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
 			return
 		}
-		tokenStr := parts[1]
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
-		if err != nil || !token.Valid {
+		claims, err := ParseToken(parts[1])
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			return
 		}
+		state, err := checkRevoked(claims)
+		if errors.Is(err, ErrTokenRevoked) || errors.Is(err, ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token revoked"})
+			return
+		} else if errors.Is(err, ErrUserDisabled) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+			return
+		} else if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if claims.SessionID != "" && time.Since(state.SessionUsedAt) > sessionTouchInterval {
+			touchSession(claims.SessionID, c.ClientIP(), c.Request.UserAgent())
+		}
 		c.Set("userID", claims.UserID)
+		c.Set("claims", claims)
+		c.Set("role", state.Role)
+		c.Next()
+	}
+}
+
+// RequireRole - доступ к маршруту только пользователям с ролью role
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("role") != role {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LestaStartTest/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit - ограничение частоты запросов с одного IP; name разделяет корзины разных эндпоинтов.
+// Если хранилище ограничений недоступно, запрос пропускается: вход важнее защиты от перебора.
+func RateLimit(name string, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, wait, err := ratelimit.Default.Allow(c.Request.Context(), name+":ip:"+c.ClientIP(), limit)
+		if err != nil {
+			log.Printf("Rate limit check failed: %v", err)
+		} else if !ok {
+			AbortTooManyRequests(c, wait, "Too many requests, try again later")
+			return
+		}
+		c.Next()
+	}
+}
+
+// AbortTooManyRequests - ответ 429 с заголовком Retry-After в целых секундах
+func AbortTooManyRequests(c *gin.Context, wait time.Duration, message string) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": message, "retry_after": seconds})
+}
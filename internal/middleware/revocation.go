@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/models"
+)
+
+// Время последнего обращения сессии обновляется не чаще раза в минуту, чтобы не писать в БД на каждый запрос
+const sessionTouchInterval = time.Minute
+
+var (
+	ErrTokenRevoked = errors.New("token revoked")
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserDisabled = errors.New("user disabled")
+)
+
+// tokenState - состояние владельца токена на момент запроса
+type tokenState struct {
+	Role          string
+	SessionUsedAt time.Time // последнее обращение сессии токена
+}
+
+// checkRevoked - access-токен недействителен, если его jti отозван, пользователь удалён или заблокирован,
+// сессия завершена или токен выдан раньше отзыва всех токенов пользователя (смена пароля)
+func checkRevoked(claims *Claims) (tokenState, error) {
+	var state struct {
+		Role             string
+		Disabled         bool
+		TokensValidAfter *time.Time
+		SessionUsedAt    *time.Time
+		Revoked          bool
+	}
+	res := db.DB.Model(&models.User{}).
+		Select("users.role, users.disabled, users.tokens_valid_after, sessions.last_used_at AS session_used_at, "+
+			"EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = ?) AS revoked", claims.ID).
+		Joins("LEFT JOIN sessions ON sessions.id = ? AND sessions.user_id = users.id", claims.SessionID).
+		Where("users.id = ?", claims.UserID).
+		Scan(&state)
+	if res.Error != nil {
+		return tokenState{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return tokenState{}, ErrUserNotFound
+	}
+	if state.Disabled {
+		return tokenState{}, ErrUserDisabled
+	}
+	if state.Revoked || (state.TokensValidAfter != nil && (claims.IssuedAt == nil || claims.IssuedAt.Unix() < state.TokensValidAfter.Unix())) {
+		return tokenState{}, ErrTokenRevoked
+	}
+	result := tokenState{Role: state.Role}
+	if claims.SessionID == "" {
+		return result, nil
+	}
+	if state.SessionUsedAt == nil {
+		return tokenState{}, ErrTokenRevoked
+	}
+	result.SessionUsedAt = *state.SessionUsedAt
+	return result, nil
+}
+
+// touchSession - запоминание времени, адреса и клиента последнего обращения сессии
+func touchSession(sessionID, ip, userAgent string) {
+	err := db.DB.Model(&models.Session{}).Where("id = ?", sessionID).Updates(map[string]interface{}{
+		"last_used_at": time.Now(),
+		"ip":           ip,
+		"user_agent":   TruncateUserAgent(userAgent),
+	}).Error
+	if err != nil {
+		log.Printf("Failed to update session %s: %v", sessionID, err)
+	}
+}
+
+// TruncateUserAgent - User-Agent, обрезанный до размера столбца sessions.user_agent
+func TruncateUserAgent(userAgent string) string {
+	const maxLength = 512
+	if len(userAgent) > maxLength {
+		return userAgent[:maxLength]
+	}
+	return userAgent
+}
+
+// RevokeToken - отзыв access-токена до окончания срока его действия (выход из аккаунта)
+func RevokeToken(claims *Claims) error {
+	if claims.ID == "" {
+		return nil
+	}
+	return db.DB.Create(&models.RevokedToken{
+		JTI:       claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}).Error
+}
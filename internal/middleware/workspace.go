@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"LestaStartTest/internal/db"
+	"LestaStartTest/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WorkspaceHeader - заголовок, которым выбирается активное рабочее пространство
+const WorkspaceHeader = "X-Workspace-ID"
+
+// Workspace - выбор активного рабочего пространства по заголовку X-Workspace-ID. Без заголовка запрос
+// работает с личными документами пользователя. Участнику с ролью viewer доступны только запросы на чтение.
+func Workspace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader(WorkspaceHeader)
+		if header == "" {
+			c.Next()
+			return
+		}
+		workspaceID, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid " + WorkspaceHeader})
+			return
+		}
+
+		var member models.WorkspaceMember
+		err = db.DB.Where("workspace_id = ? AND user_id = ?", workspaceID, c.MustGet("userID")).First(&member).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+			return
+		} else if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if member.Role == models.AccessViewer && !isSafeMethod(c.Request.Method) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Workspace viewers cannot make changes"})
+			return
+		}
+
+		c.Set("workspaceID", member.WorkspaceID)
+		c.Set("workspaceRole", member.Role)
+		c.Next()
+	}
+}
@@ -2,40 +2,242 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// Роли пользователей
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
 )
 
 type User struct {
+	ID               uint       `gorm:"primary_key"`
+	Username         string     `gorm:"unique;not null"`
+	Password         string     `gorm:"not null"`
+	Role             string     `gorm:"size:16;not null;default:user"`
+	Disabled         bool       `gorm:"not null;default:false"` // заблокированный пользователь не может войти, его токены и ключи не принимаются
+	TokensValidAfter *time.Time // токены, выданные раньше, отозваны (смена пароля)
+	CreatedAt        time.Time
+}
+
+// Session - вход в аккаунт с одного устройства. Все refresh-токены входа относятся к сессии (Family = ID),
+// access-токены несут её ID в claim sid; удаление сессии отзывает и те, и другие.
+type Session struct {
+	ID         string    `gorm:"primaryKey;size:32"`
+	UserID     uint      `gorm:"not null;index"`
+	IP         string    `gorm:"size:64"`  // адрес последнего обращения
+	UserAgent  string    `gorm:"size:512"` // клиент последнего обращения
+	LastUsedAt time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null;index"` // продлевается при каждом обновлении токенов
+	CreatedAt  time.Time
+}
+
+// RefreshToken - refresh-токен. Хранится только SHA-256 токена; при обмене выдаётся новый токен той же цепочки (Family).
+type RefreshToken struct {
+	ID        uint       `gorm:"primary_key"`
+	UserID    uint       `gorm:"not null;index"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex"`
+	Family    string     `gorm:"size:32;not null;index"` // цепочка токенов одного входа (ID сессии)
+	ExpiresAt time.Time  `gorm:"not null;index"`
+	UsedAt    *time.Time // время обмена; повторное предъявление отзывает всю цепочку
+	CreatedAt time.Time
+}
+
+// APIKey - долгоживущий ключ для скриптов. Хранится только SHA-256 ключа, открытый текст показывается один раз.
+type APIKey struct {
+	ID         uint      `gorm:"primary_key"`
+	UserID     uint      `gorm:"not null;index"`
+	Name       string    `gorm:"size:100;not null"`
+	Prefix     string    `gorm:"size:16;not null"` // начало ключа, чтобы отличать ключи в списке
+	KeyHash    string    `gorm:"size:64;not null;uniqueIndex"`
+	Scope      string    `gorm:"size:16;not null"` // read, write или admin
+	ExpiresAt  time.Time `gorm:"not null;index"`
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// TwoFactor - второй фактор входа: секрет TOTP (RFC 6238). Пока настройка не подтверждена кодом (ConfirmedAt nil), вход его не требует.
+type TwoFactor struct {
+	UserID      uint   `gorm:"primaryKey"`
+	Secret      string `gorm:"size:64;not null"`   // base32
+	LastCounter int64  `gorm:"not null;default:0"` // период последнего принятого кода: один код не принимается дважды
+	ConfirmedAt *time.Time
+	CreatedAt   time.Time
+}
+
+// RecoveryCode - одноразовый код восстановления на случай потери приложения-аутентификатора. Хранится только SHA-256 кода.
+type RecoveryCode struct {
 	ID        uint   `gorm:"primary_key"`
-	Username  string `gorm:"unique;not null"`
-	Password  string `gorm:"not null"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"size:64;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// LoginChallenge - незавершённый вход с двухфакторной аутентификацией: пароль проверен, ожидается код.
+// Хранится только SHA-256 токена; число попыток ввода кода ограничено.
+type LoginChallenge struct {
+	TokenHash string    `gorm:"primaryKey;size:64"`
+	UserID    uint      `gorm:"not null;index"`
+	Attempts  int       `gorm:"not null;default:0"`
+	ExpiresAt time.Time `gorm:"not null;index"`
 	CreatedAt time.Time
 }
 
+// RevokedToken - отозванный до истечения срока access-токен. Хранится, пока токен не истечёт.
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:32"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// Document - документ. У документа рабочего пространства UserID - загрузивший его участник, в квоте которого он учитывается.
 type Document struct {
-	ID               uint   `gorm:"primary_key"`
-	UserID           uint   `gorm:"not null;index:idx_user_file,priority:1"`
-	Filename         string `gorm:"not null;index:idx_user_file,priority:2,unique"`
+	ID          uint   `gorm:"primary_key"`
+	UserID      uint   `gorm:"not null;index;uniqueIndex:idx_documents_personal_filename_active,priority:1,where:deleted_at IS NULL AND workspace_id IS NULL"`
+	WorkspaceID *uint  `gorm:"uniqueIndex:idx_documents_workspace_filename_active,priority:1,where:deleted_at IS NULL"` // nil - личный документ
+	Filename    string `gorm:"not null;uniqueIndex:idx_documents_personal_filename_active,priority:2;uniqueIndex:idx_documents_workspace_filename_active,priority:2"`
+	BlobHash    string `gorm:"size:64;not null;index"` // содержимое текущей версии
+	Version     int    `gorm:"not null;default:1"`     // номер текущей версии
+	Title       string // метаданные, которые задаёт пользователь
+	Author      string
+	SourceURL   string
+	Fields      map[string]string `gorm:"type:text;serializer:json"` // дополнительные поля "ключ - значение"
+	CreatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"` // время перемещения в корзину
+
+	Blob        *Blob             `gorm:"foreignKey:BlobHash;references:Hash"`
+	Versions    []DocumentVersion `gorm:"constraint:OnDelete:CASCADE;"`
+	Tags        []DocumentTag     `gorm:"constraint:OnDelete:CASCADE;"`
+	Collections []*Collection     `gorm:"many2many:collection_documents;constraint:OnDelete:CASCADE;"`
+}
+
+// DocumentVersion - версия документа. Каждая версия держит одну ссылку на своё содержимое (Blob.RefCount).
+type DocumentVersion struct {
+	ID         uint   `gorm:"primary_key"`
+	DocumentID uint   `gorm:"not null;uniqueIndex:idx_document_versions_number,priority:1"`
+	Number     int    `gorm:"not null;uniqueIndex:idx_document_versions_number,priority:2"`
+	BlobHash   string `gorm:"size:64;not null;index"`
+	CreatedAt  time.Time
+
+	Blob *Blob `gorm:"foreignKey:BlobHash;references:Hash"`
+}
+
+// DocumentTag - тег документа. Теги хранятся отдельной таблицей, чтобы по ним можно было искать документы.
+type DocumentTag struct {
+	DocumentID uint   `gorm:"primaryKey"`
+	Tag        string `gorm:"primaryKey;size:64;index"` // в нижнем регистре
+}
+
+// Blob - содержимое загруженного файла, общее для всех документов с одинаковым SHA-256
+type Blob struct {
+	Hash             string `gorm:"primaryKey;size:64"`
+	StorageKey       string `gorm:"not null"`
+	Size             int64  `gorm:"not null"`
 	Content          string `gorm:"type:text;not null"`
-	OriginalPath     string `gorm:"not null"`
 	ProcessedContent string `gorm:"type:text;not null"`
+	Encoding         string `gorm:"not null;default:'UTF-8'"`
+	MimeType         string `gorm:"not null;default:'text/plain'"`
+	RefCount         int    `gorm:"not null;default:0"`
 	CreatedAt        time.Time
-
-	Collections []*Collection `gorm:"many2many:collection_documents;constraint:OnDelete:CASCADE;"`
 }
 
 type Collection struct {
-	ID        uint   `gorm:"primary_key"`
-	UserID    uint   `gorm:"not null;index"`
-	Name      string `gorm:"not null"`
-	CreatedAt time.Time
+	ID          uint   `gorm:"primary_key"`
+	UserID      uint   `gorm:"not null;index"` // создатель коллекции
+	WorkspaceID *uint  `gorm:"index"`          // рабочее пространство; nil - личная коллекция
+	Name        string `gorm:"not null"`
+	CreatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"` // время перемещения в корзину
 
 	IDFRecords []CollectionIDF `gorm:"constraint:OnDelete:CASCADE;"`
 	Documents  []*Document     `gorm:"many2many:collection_documents;"`
 }
 
+// Уровни доступа к коллекции: владелец, редактор (может добавлять свои документы) и читатель.
+// Те же значения - роли участников рабочего пространства.
+const (
+	AccessOwner  = "owner"
+	AccessEditor = "editor"
+	AccessViewer = "viewer"
+)
+
+// Workspace - рабочее пространство: общие документы и коллекции нескольких пользователей
+type Workspace struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:100;not null"`
+	CreatedAt time.Time
+}
+
+// WorkspaceMember - участник рабочего пространства. owner управляет участниками,
+// editor загружает и изменяет документы и коллекции, viewer только читает.
+type WorkspaceMember struct {
+	WorkspaceID uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"primaryKey;index"`
+	Role        string `gorm:"size:16;not null"`
+	CreatedAt   time.Time
+}
+
+// CollectionShare - доступ другого пользователя к коллекции
+type CollectionShare struct {
+	CollectionID uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"primaryKey;index"`
+	Role         string `gorm:"size:16;not null"` // editor или viewer
+	CreatedAt    time.Time
+}
+
+// ShareLink - публичная ссылка только для чтения на коллекцию и её статистику. Хранится только хэш токена.
+type ShareLink struct {
+	ID           uint       `gorm:"primaryKey"`
+	CollectionID uint       `gorm:"not null;index"`
+	Prefix       string     `gorm:"size:16;not null"` // начало токена, чтобы отличать ссылки в списке
+	TokenHash    string     `gorm:"size:64;not null;uniqueIndex"`
+	AllowContent bool       `gorm:"not null;default:false"` // разрешено получать текст документов
+	ExpiresAt    *time.Time `gorm:"index"`                  // nil - бессрочная
+	CreatedAt    time.Time
+}
+
 type CollectionIDF struct {
 	ID           uint    `gorm:"primary_key"`
 	CollectionID uint    `gorm:"not null;index"`
 	Word         string  `gorm:"not null;index"`
 	IDFValue     float64 `gorm:"not null"`
 }
+
+// Job - фоновая задача. Хранится в БД, поэтому незавершённые задачи продолжаются после перезапуска.
+type Job struct {
+	ID         uint     `gorm:"primary_key"`
+	UserID     uint     `gorm:"not null;index"`
+	Type       string   `gorm:"size:32;not null"`
+	Status     string   `gorm:"size:16;not null;index"`
+	Total      int      `gorm:"not null;default:0"`        // количество шагов (файлов)
+	Processed  int      `gorm:"not null;default:0"`        // выполнено шагов
+	Attempts   int      `gorm:"not null;default:0"`        // количество запусков
+	Payload    string   `gorm:"type:text"`                 // входные параметры в JSON
+	Files      []string `gorm:"type:text;serializer:json"` // временные файлы в хранилище, удаляются после завершения
+	Errors     []string `gorm:"type:text;serializer:json"` // ошибки отдельных шагов
+	Error      string   `gorm:"type:text"`                 // причина неудачи задачи
+	Result     string   `gorm:"type:text"`                 // результат в JSON
+	Checkpoint string   `gorm:"type:text"`                 // сохранённое обработчиком состояние в JSON, с него продолжается повторный запуск
+	CreatedAt  time.Time
+	UpdatedAt  time.Time // обновляется во время выполнения, по нему находятся зависшие задачи
+	FinishedAt *time.Time
+}
+
+// UploadSession - сессия возобновляемой загрузки файла по частям. Части хранятся отдельными объектами.
+type UploadSession struct {
+	ID                string   `gorm:"primaryKey;size:32"`
+	UserID            uint     `gorm:"not null;index"`
+	WorkspaceID       *uint    // рабочее пространство, в которое загружается файл
+	Filename          string   `gorm:"not null"`
+	Size              int64    `gorm:"not null"`                  // заявленный размер файла
+	Received          int64    `gorm:"not null;default:0"`        // сколько байт уже получено (Upload-Offset)
+	Chunks            []string `gorm:"type:text;serializer:json"` // ключи частей в хранилище по порядку
+	Encoding          string
+	OnConflict        string
+	ArchiveCollection bool
+	Atomic            bool
+	CreatedAt         time.Time
+	ExpiresAt         time.Time `gorm:"not null;index"` // продлевается при получении каждой части
+}
@@ -0,0 +1,103 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Размер страницы по умолчанию и максимальный
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidLimit  = fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+)
+
+// Sort - поле сортировки и направление. В запросе записывается как "name" или "-name" (по убыванию).
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// ParseSort - сортировка из параметра запроса; пустое значение - def
+func ParseSort(value string, allowed []string, def Sort) (Sort, error) {
+	if value == "" {
+		return def, nil
+	}
+	s := Sort{Field: strings.TrimPrefix(value, "-"), Desc: strings.HasPrefix(value, "-")}
+	if !slices.Contains(allowed, s.Field) {
+		return s, fmt.Errorf("sort must be one of %s, with optional - for descending order", strings.Join(allowed, ", "))
+	}
+	return s, nil
+}
+
+// ParseLimit - размер страницы из параметра запроса; пустое значение - DefaultLimit
+func ParseLimit(value string) (int, error) {
+	if value == "" {
+		return DefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > MaxLimit {
+		return 0, ErrInvalidLimit
+	}
+	return limit, nil
+}
+
+// Cursor - позиция последней выданной строки: значение поля сортировки и ID для однозначного порядка
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// Encode - непрозрачная строка курсора для next_cursor
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor - курсор из параметра запроса. Курсор действителен только для той же сортировки, с которой выдан.
+func DecodeCursor(token string, sort Sort) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.ID == 0 {
+		return c, ErrInvalidCursor
+	}
+	if c.Sort != sort.String() {
+		return c, fmt.Errorf("%w: issued for sort=%s", ErrInvalidCursor, c.Sort)
+	}
+	return c, nil
+}
+
+// ParseFields - список полей ответа через запятую. Пустое значение - все поля (nil).
+func ParseFields(value string, allowed []string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if !slices.Contains(allowed, field) {
+			return nil, fmt.Errorf("unknown field %q, allowed: %s", field, strings.Join(allowed, ", "))
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,69 @@
+package pagination
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var allowed = []string{"name", "created_at", "size"}
+
+func TestParseSort(t *testing.T) {
+	def := Sort{Field: "created_at"}
+	s, err := ParseSort("", allowed, def)
+	require.NoError(t, err)
+	assert.Equal(t, def, s)
+
+	s, err = ParseSort("-size", allowed, def)
+	require.NoError(t, err)
+	assert.Equal(t, Sort{Field: "size", Desc: true}, s)
+	assert.Equal(t, "-size", s.String())
+
+	_, err = ParseSort("content", allowed, def)
+	assert.Error(t, err)
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLimit, limit)
+
+	limit, err = ParseLimit("10")
+	require.NoError(t, err)
+	assert.Equal(t, 10, limit)
+
+	for _, value := range []string{"0", "-1", "201", "ten"} {
+		_, err = ParseLimit(value)
+		assert.ErrorIs(t, err, ErrInvalidLimit, value)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	sort := Sort{Field: "name", Desc: true}
+	cursor := Cursor{Sort: sort.String(), Value: "отчёт.txt", ID: 42}
+
+	decoded, err := DecodeCursor(cursor.Encode(), sort)
+	require.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	_, err = DecodeCursor(cursor.Encode(), Sort{Field: "name"})
+	assert.ErrorIs(t, err, ErrInvalidCursor, "курсор другой сортировки")
+	_, err = DecodeCursor("not a cursor", sort)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = DecodeCursor(Cursor{Sort: "-name"}.Encode(), sort)
+	assert.ErrorIs(t, err, ErrInvalidCursor, "курсор без ID")
+}
+
+func TestParseFields(t *testing.T) {
+	fields, err := ParseFields("", allowed)
+	require.NoError(t, err)
+	assert.Nil(t, fields)
+
+	fields, err = ParseFields("name, size,name", allowed)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "size"}, fields)
+
+	_, err = ParseFields("name,content", allowed)
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+var (
+	ErrFileTooLarge      = errors.New("file exceeds maximum size")
+	ErrTooManyFiles      = errors.New("too many files in one request")
+	ErrStorageExceeded   = errors.New("storage quota exceeded")
+	ErrDocumentsExceeded = errors.New("document quota exceeded")
+)
+
+// Limits - ограничения для одного пользователя. Нулевое значение - без ограничения.
+type Limits struct {
+	MaxBytes     int64 `json:"max_bytes"`             // суммарный размер всех версий документов
+	MaxDocuments int64 `json:"max_documents"`         // количество документов
+	MaxFileSize  int64 `json:"max_file_size"`         // размер одного файла
+	MaxFiles     int   `json:"max_files_per_request"` // количество файлов в одном запросе
+}
+
+// Usage - текущее потребление пользователя
+type Usage struct {
+	Bytes     int64 `json:"bytes"`
+	Documents int64 `json:"documents"`
+}
+
+// LimitsFromEnv - ограничения из переменных окружения QUOTA_MAX_BYTES, QUOTA_MAX_DOCUMENTS,
+// UPLOAD_MAX_FILE_SIZE и UPLOAD_MAX_FILES
+func LimitsFromEnv() Limits {
+	limits := Limits{
+		MaxBytes:     1 << 30,
+		MaxDocuments: 10000,
+		MaxFileSize:  50 << 20,
+		MaxFiles:     100,
+	}
+	if v, err := strconv.ParseInt(os.Getenv("QUOTA_MAX_BYTES"), 10, 64); err == nil && v >= 0 {
+		limits.MaxBytes = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("QUOTA_MAX_DOCUMENTS"), 10, 64); err == nil && v >= 0 {
+		limits.MaxDocuments = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("UPLOAD_MAX_FILE_SIZE"), 10, 64); err == nil && v >= 0 {
+		limits.MaxFileSize = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("UPLOAD_MAX_FILES")); err == nil && v >= 0 {
+		limits.MaxFiles = v
+	}
+	return limits
+}
+
+// RequestBodyLimit - максимальный размер тела запроса на загрузку (0 - без ограничения).
+// Запас в 1 МБ на заголовки multipart и поля формы.
+func (l Limits) RequestBodyLimit() int64 {
+	if l.MaxFileSize == 0 || l.MaxFiles == 0 {
+		return 0
+	}
+	return l.MaxFileSize*int64(l.MaxFiles) + 1<<20
+}
+
+// CheckFiles - проверка количества файлов в запросе
+func (l Limits) CheckFiles(n int) error {
+	if l.MaxFiles > 0 && n > l.MaxFiles {
+		return fmt.Errorf("%w: %d files, limit is %d", ErrTooManyFiles, n, l.MaxFiles)
+	}
+	return nil
+}
+
+// CheckFile - проверка размера одного файла
+func (l Limits) CheckFile(name string, size int64) error {
+	if l.MaxFileSize > 0 && size > l.MaxFileSize {
+		return fmt.Errorf("%w: %s is %s, limit is %s", ErrFileTooLarge, name, FormatBytes(size), FormatBytes(l.MaxFileSize))
+	}
+	return nil
+}
+
+// CheckUsage - проверка, что после добавления add потребление останется в пределах квоты
+func (l Limits) CheckUsage(current, add Usage) error {
+	if l.MaxBytes > 0 && current.Bytes+add.Bytes > l.MaxBytes {
+		return fmt.Errorf("%w: %s used of %s, upload needs %s",
+			ErrStorageExceeded, FormatBytes(current.Bytes), FormatBytes(l.MaxBytes), FormatBytes(add.Bytes))
+	}
+	if l.MaxDocuments > 0 && current.Documents+add.Documents > l.MaxDocuments {
+		return fmt.Errorf("%w: %d documents of %d, upload adds %d",
+			ErrDocumentsExceeded, current.Documents, l.MaxDocuments, add.Documents)
+	}
+	return nil
+}
+
+// FormatBytes - размер в читаемом виде: 512 B, 1.5 KB, 20.0 MB
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,41 @@
+package quota
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var limits = Limits{MaxBytes: 1000, MaxDocuments: 3, MaxFileSize: 400, MaxFiles: 2}
+
+func TestCheckFiles(t *testing.T) {
+	assert.NoError(t, limits.CheckFiles(2))
+	assert.ErrorIs(t, limits.CheckFiles(3), ErrTooManyFiles)
+	assert.NoError(t, Limits{}.CheckFiles(1000), "нулевой лимит - без ограничения")
+}
+
+func TestCheckFile(t *testing.T) {
+	assert.NoError(t, limits.CheckFile("a.txt", 400))
+	err := limits.CheckFile("a.txt", 401)
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+	assert.Contains(t, err.Error(), "a.txt")
+}
+
+func TestCheckUsage(t *testing.T) {
+	assert.NoError(t, limits.CheckUsage(Usage{Bytes: 600, Documents: 2}, Usage{Bytes: 400, Documents: 1}))
+	assert.ErrorIs(t, limits.CheckUsage(Usage{Bytes: 600}, Usage{Bytes: 401}), ErrStorageExceeded)
+	assert.ErrorIs(t, limits.CheckUsage(Usage{Documents: 3}, Usage{Documents: 1}), ErrDocumentsExceeded)
+	assert.NoError(t, limits.CheckUsage(Usage{Documents: 3}, Usage{Bytes: 10}), "новая версия не добавляет документ")
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	assert.Equal(t, int64(800+1<<20), limits.RequestBodyLimit())
+	assert.Equal(t, int64(0), Limits{MaxFiles: 2}.RequestBodyLimit())
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", FormatBytes(512))
+	assert.Equal(t, "1.5 KB", FormatBytes(1536))
+	assert.Equal(t, "50.0 MB", FormatBytes(50<<20))
+	assert.Equal(t, "1.0 GB", FormatBytes(1<<30))
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Lockout - блокировка после неудачных попыток подряд. После Threshold неудач key блокируется на Base,
+// каждая следующая неудача удваивает блокировку до Max. Счётчик неудач сбрасывается успехом или через Window без неудач.
+type Lockout struct {
+	Store     Store
+	Threshold int // 0 - блокировка отключена
+	Base      time.Duration
+	Max       time.Duration
+	Window    time.Duration
+}
+
+// LockoutFromEnv - блокировка из LOGIN_LOCKOUT_THRESHOLD, LOGIN_LOCKOUT_BASE, LOGIN_LOCKOUT_MAX и LOGIN_LOCKOUT_WINDOW
+// (по умолчанию 5 неудач, от 1 минуты до 1 часа, счётчик живёт сутки) в хранилище Default
+func LockoutFromEnv() Lockout {
+	l := Lockout{
+		Store:     Default,
+		Threshold: 5,
+		Base:      durationFromEnv("LOGIN_LOCKOUT_BASE", time.Minute),
+		Max:       durationFromEnv("LOGIN_LOCKOUT_MAX", time.Hour),
+		Window:    durationFromEnv("LOGIN_LOCKOUT_WINDOW", 24*time.Hour),
+	}
+	if v, err := strconv.Atoi(os.Getenv("LOGIN_LOCKOUT_THRESHOLD")); err == nil && v >= 0 {
+		l.Threshold = v
+	}
+	return l
+}
+
+// Duration - срок блокировки после failures неудач подряд
+func (l Lockout) Duration(failures int) time.Duration {
+	if l.Threshold <= 0 || failures < l.Threshold {
+		return 0
+	}
+	d := l.Base
+	for i := l.Threshold; i < failures && d < l.Max; i++ {
+		d *= 2
+	}
+	if l.Max > 0 && d > l.Max {
+		d = l.Max
+	}
+	return d
+}
+
+// Check - сколько ещё заблокирован key
+func (l Lockout) Check(ctx context.Context, key string) (time.Duration, error) {
+	if l.Threshold <= 0 {
+		return 0, nil
+	}
+	return l.Store.LockedFor(ctx, "lockout:"+key)
+}
+
+// Fail - учёт неудачной попытки; возвращает срок блокировки, если key теперь заблокирован
+func (l Lockout) Fail(ctx context.Context, key string) (time.Duration, error) {
+	if l.Threshold <= 0 {
+		return 0, nil
+	}
+	failures, err := l.Store.Incr(ctx, "lockout:"+key, l.Window)
+	if err != nil {
+		return 0, err
+	}
+	d := l.Duration(failures)
+	if d > 0 {
+		err = l.Store.Lock(ctx, "lockout:"+key, d)
+	}
+	return d, err
+}
+
+// Success - сброс неудач после успешной попытки
+func (l Lockout) Success(ctx context.Context, key string) error {
+	if l.Threshold <= 0 {
+		return nil
+	}
+	return l.Store.Reset(ctx, "lockout:"+key)
+}
+
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", name, v, def)
+		return def
+	}
+	return d
+}
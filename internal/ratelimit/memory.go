@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Как часто из памяти удаляются полные корзины и истёкшие счётчики
+const memorySweepInterval = time.Minute
+
+// MemoryStore - хранилище в памяти процесса
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	counters  map[string]*counter
+	locks     map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+type counter struct {
+	value   int
+	expires time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  map[string]*bucket{},
+		counters: map[string]*counter{},
+		locks:    map[string]time.Time{},
+		now:      time.Now,
+	}
+}
+
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	if limit.Unlimited() {
+		return true, 0, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.refill(now, limit)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	return false, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second)), nil
+}
+
+func (s *MemoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expires) {
+		c = &counter{}
+		s.counters[key] = c
+	}
+	c.value++
+	c.expires = now.Add(ttl)
+	return c.value, nil
+}
+
+func (s *MemoryStore) Lock(_ context.Context, key string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locks[key] = s.now().Add(d)
+	return nil
+}
+
+func (s *MemoryStore) LockedFor(_ context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.locks[key]
+	if !ok {
+		return 0, nil
+	}
+	left := until.Sub(s.now())
+	if left <= 0 {
+		delete(s.locks, key)
+		return 0, nil
+	}
+	return left, nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.buckets, key)
+		delete(s.counters, key)
+		delete(s.locks, key)
+	}
+	return nil
+}
+
+// sweep - удаление записей, которые больше ни на что не влияют: полных корзин, истёкших счётчиков и блокировок
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if b.refill(now, b.limit); b.tokens >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, c := range s.counters {
+		if !now.Before(c.expires) {
+			delete(s.counters, key)
+		}
+	}
+	for key, until := range s.locks {
+		if !now.Before(until) {
+			delete(s.locks, key)
+		}
+	}
+}
+
+// refill - пополнение корзины за время, прошедшее с прошлого обращения
+func (b *bucket) refill(now time.Time, limit Limit) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	}
+	b.updated = now
+	b.limit = limit
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit - параметры token bucket: Burst запросов подряд, затем Rate запросов в секунду.
+// Нулевой Limit - без ограничения.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited - лимит не задан
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Per - n запросов за period: корзина на n токенов, пополняемая равномерно за period
+func Per(n int, period time.Duration) Limit {
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: n}
+}
+
+// ParseLimit - лимит в виде "N/период": "5/m", "100/h", "20/30s"; "0" или "off" - без ограничения
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "0" || s == "off" {
+		return Limit{}, nil
+	}
+	count, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: want N/period", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad count", s)
+	}
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad period", s)
+	}
+	return Per(n, d), nil
+}
+
+// LimitFromEnv - лимит из переменной name, при её отсутствии или ошибке - def
+func LimitFromEnv(name, def string) Limit {
+	v := os.Getenv(name)
+	if v == "" {
+		v = def
+	}
+	l, err := ParseLimit(v)
+	if err != nil {
+		log.Printf("Invalid %s: %v, using %s", name, err, def)
+		l, _ = ParseLimit(def)
+	}
+	return l
+}
+
+// Store - хранилище состояния ограничений: корзины токенов, счётчики и блокировки с ограниченным сроком жизни
+type Store interface {
+	// Allow - забирает токен из корзины key. Если токенов нет, возвращает false и время до появления токена.
+	Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+	// Incr - увеличивает счётчик key и продлевает его жизнь на ttl, возвращает новое значение
+	Incr(ctx context.Context, key string, ttl time.Duration) (int, error)
+	// Lock - блокировка key на d
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockedFor - сколько ещё действует блокировка key (0 - не заблокирован)
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Reset - удаление счётчиков и блокировок keys
+	Reset(ctx context.Context, keys ...string) error
+}
+
+// Default - хранилище, выбранное при инициализации приложения
+var Default Store = NewMemoryStore()
+
+// Init - выбор хранилища по переменной RATE_LIMIT_BACKEND (memory или redis).
+// В памяти ограничения действуют отдельно на каждом экземпляре приложения.
+func Init() {
+	var err error
+	switch backend := os.Getenv("RATE_LIMIT_BACKEND"); backend {
+	case "", "memory":
+		Default = NewMemoryStore()
+	case "redis":
+		Default, err = NewRedisStore(context.Background(), RedisConfigFromEnv())
+	default:
+		err = fmt.Errorf("unknown RATE_LIMIT_BACKEND %q", backend)
+	}
+	if err != nil {
+		log.Fatalf("Failed to initialize rate limit store: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	l, err := ParseLimit("5/m")
+	require.NoError(t, err)
+	assert.Equal(t, 5, l.Burst)
+	assert.InDelta(t, 5.0/60, l.Rate, 1e-9)
+
+	l, err = ParseLimit("20/30s")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Rate: 20.0 / 30, Burst: 20}, l)
+
+	l, err = ParseLimit("off")
+	require.NoError(t, err)
+	assert.True(t, l.Unlimited())
+
+	for _, bad := range []string{"", "5", "x/m", "-1/m", "5/x", "5/0s"} {
+		_, err := ParseLimit(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	l := Lockout{Threshold: 3, Base: time.Minute, Max: 10 * time.Minute}
+	assert.Equal(t, time.Duration(0), l.Duration(2))
+	assert.Equal(t, time.Minute, l.Duration(3))
+	assert.Equal(t, 2*time.Minute, l.Duration(4))
+	assert.Equal(t, 8*time.Minute, l.Duration(6))
+	assert.Equal(t, 10*time.Minute, l.Duration(7), "не больше Max")
+	assert.Equal(t, 10*time.Minute, l.Duration(100))
+	assert.Equal(t, time.Duration(0), Lockout{}.Duration(100), "нулевой порог - без блокировки")
+}
+
+// testStore - общие проверки для всех реализаций Store; ключи уникальны для каждого запуска
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+	key := t.Name() + time.Now().Format(time.RFC3339Nano)
+	limit := Limit{Rate: 10, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		ok, _, err := s.Allow(ctx, key, limit)
+		require.NoError(t, err)
+		assert.True(t, ok, "запрос %d в пределах burst", i)
+	}
+	ok, wait, err := s.Allow(ctx, key, limit)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Greater(t, wait, time.Duration(0))
+	assert.LessOrEqual(t, wait, 100*time.Millisecond)
+
+	time.Sleep(wait + 20*time.Millisecond)
+	ok, _, err = s.Allow(ctx, key, limit)
+	require.NoError(t, err)
+	assert.True(t, ok, "токен пополнился")
+
+	ok, _, err = s.Allow(ctx, key+"other", limit)
+	require.NoError(t, err)
+	assert.True(t, ok, "корзины ключей независимы")
+
+	// Блокировка по неудачам
+	lockout := Lockout{Store: s, Threshold: 2, Base: time.Minute, Max: time.Hour, Window: time.Hour}
+	d, err := lockout.Fail(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), d)
+	left, err := lockout.Check(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), left)
+
+	d, err = lockout.Fail(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, d)
+	left, err = lockout.Check(ctx, key)
+	require.NoError(t, err)
+	assert.InDelta(t, float64(time.Minute), float64(left), float64(time.Second))
+
+	d, err = lockout.Fail(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, d, "блокировка растёт с каждой неудачей")
+
+	require.NoError(t, lockout.Success(ctx, key))
+	left, err = lockout.Check(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), left)
+	d, err = lockout.Fail(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), d, "успех сбрасывает счётчик")
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	n, _ := s.Incr(ctx, "k", time.Minute)
+	assert.Equal(t, 1, n)
+	now = now.Add(30 * time.Second)
+	n, _ = s.Incr(ctx, "k", time.Minute)
+	assert.Equal(t, 2, n, "срок продлевается каждым увеличением")
+	now = now.Add(time.Minute)
+	n, _ = s.Incr(ctx, "k", time.Minute)
+	assert.Equal(t, 1, n, "истёкший счётчик начинается заново")
+
+	require.NoError(t, s.Lock(ctx, "k", time.Minute))
+	now = now.Add(time.Minute)
+	left, _ := s.LockedFor(ctx, "k")
+	assert.Equal(t, time.Duration(0), left)
+
+	s.Allow(ctx, "b", Per(1, time.Minute))
+	now = now.Add(2 * time.Minute)
+	s.Allow(ctx, "c", Per(1, time.Minute))
+	assert.NotContains(t, s.buckets, "b", "полная корзина удаляется")
+	assert.Contains(t, s.buckets, "c")
+}
+
+// TestRedisStore выполняется против Redis, например из docker-compose:
+// REDIS_TEST_ADDR=localhost:6379 go test ./internal/ratelimit
+func TestRedisStore(t *testing.T) {
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR is not set")
+	}
+	s, err := NewRedisStore(context.Background(), RedisConfig{Addr: addr, Prefix: "ratelimit-test:"})
+	require.NoError(t, err)
+	defer s.Close()
+	testStore(t, s)
+}
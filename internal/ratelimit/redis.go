@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisConfig - параметры подключения к Redis
+type RedisConfig struct {
+	Addr     string // host:port
+	Password string
+	DB       int
+	Prefix   string // префикс ключей
+}
+
+// RedisConfigFromEnv - параметры из переменных окружения REDIS_*
+func RedisConfigFromEnv() RedisConfig {
+	db, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+	prefix := os.Getenv("REDIS_PREFIX")
+	if prefix == "" {
+		prefix = "ratelimit:"
+	}
+	return RedisConfig{
+		Addr:     os.Getenv("REDIS_ADDR"),
+		Password: os.Getenv("REDIS_PASSWORD"),
+		DB:       db,
+		Prefix:   prefix,
+	}
+}
+
+// RedisStore - хранилище в Redis, общее для всех экземпляров приложения
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// allowScript - token bucket одним атомарным шагом. Время берётся у Redis, чтобы часы экземпляров приложения не расходились.
+// Возвращает строкой время ожидания в секундах (0 - токен выдан): целые ответы Lua усекает.
+var allowScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+else
+  wait = (1 - tokens) / rate
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000))
+return tostring(wait)
+`)
+
+// NewRedisStore - подключение к Redis с проверкой доступности
+func NewRedisStore(ctx context.Context, cfg RedisConfig) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &RedisStore{client: client, prefix: cfg.Prefix}, nil
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	if limit.Unlimited() {
+		return true, 0, nil
+	}
+	res, err := allowScript.Run(ctx, s.client, []string{s.prefix + "bucket:" + key}, limit.Rate, limit.Burst).Text()
+	if err != nil {
+		return false, 0, err
+	}
+	wait, err := strconv.ParseFloat(res, 64)
+	if err != nil {
+		return false, 0, err
+	}
+	if wait <= 0 {
+		return true, 0, nil
+	}
+	return false, time.Duration(math.Ceil(wait * float64(time.Second))), nil
+}
+
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	var incr *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, s.prefix+"count:"+key)
+		pipe.PExpire(ctx, s.prefix+"count:"+key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(incr.Val()), nil
+}
+
+func (s *RedisStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	return s.client.Set(ctx, s.prefix+"lock:"+key, 1, d).Err()
+}
+
+func (s *RedisStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, s.prefix+"lock:"+key).Result()
+	if err != nil || ttl < 0 {
+		// -2: ключа нет, -1: ключ без срока (не создаётся Lock)
+		return 0, err
+	}
+	return ttl, nil
+}
+
+func (s *RedisStore) Reset(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	names := make([]string, 0, 3*len(keys))
+	for _, key := range keys {
+		names = append(names, s.prefix+"bucket:"+key, s.prefix+"count:"+key, s.prefix+"lock:"+key)
+	}
+	return s.client.Del(ctx, names...).Err()
+}
+
+// Close - закрытие соединений с Redis
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
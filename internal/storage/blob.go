@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore - хранилище исходных файлов. Ключ - путь со слешами, например "<userID>/<id>.txt".
+type BlobStore interface {
+	// Put - запись объекта; size - размер в байтах или -1, если он неизвестен
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get - чтение объекта, закрытие reader'а лежит на вызывающем
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete - удаление объекта, отсутствие объекта ошибкой не считается
+	Delete(ctx context.Context, key string) error
+	// Stat - информация об объекте без чтения содержимого
+	Stat(ctx context.Context, key string) (BlobInfo, error)
+}
+
+// BlobInfo - информация об объекте хранилища
+type BlobInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Blobs - хранилище, выбранное при инициализации приложения
+var Blobs BlobStore
+
+// Init - выбор хранилища по переменной STORAGE_BACKEND (local или s3)
+func Init() {
+	var err error
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "local":
+		Blobs, err = NewLocalStore(envOr("STORAGE_LOCAL_DIR", "uploads"))
+	case "s3":
+		Blobs, err = NewS3Store(context.Background(), S3ConfigFromEnv())
+	default:
+		err = fmt.Errorf("unknown STORAGE_BACKEND %q", backend)
+	}
+	if err != nil {
+		log.Fatalf("Failed to initialize blob storage: %v", err)
+	}
+}
+
+// ValidKey - проверка ключа: относительный путь без пустых сегментов и переходов ".."
+func ValidKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlobStore - общие проверки для всех реализаций BlobStore
+func testBlobStore(t *testing.T, s BlobStore) {
+	ctx := context.Background()
+	key := BlobKey("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+
+	require.NoError(t, s.Put(ctx, key, strings.NewReader("содержимое"), int64(len("содержимое"))))
+
+	info, err := s.Stat(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, key, info.Key)
+	assert.Equal(t, int64(len("содержимое")), info.Size)
+
+	r, err := s.Get(ctx, key)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, r.Close())
+	require.NoError(t, err)
+	assert.Equal(t, "содержимое", string(data))
+
+	// Перезапись существующего ключа
+	require.NoError(t, s.Put(ctx, key, strings.NewReader("новое"), -1))
+	info, err = s.Stat(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("новое")), info.Size)
+
+	require.NoError(t, s.Delete(ctx, key))
+	require.NoError(t, s.Delete(ctx, key), "повторное удаление не ошибка")
+
+	_, err = s.Get(ctx, key)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.Stat(ctx, key)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, bad := range []string{"", "/etc/passwd", "../x", "1/../../x", "1//x", `1\x`} {
+		assert.ErrorIs(t, s.Put(ctx, bad, strings.NewReader("x"), 1), ErrInvalidKey, bad)
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	s, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	testBlobStore(t, s)
+}
+
+// TestS3Store выполняется против MinIO, например из docker-compose:
+// S3_TEST_ENDPOINT=localhost:9000 S3_TEST_ACCESS_KEY=minioadmin S3_TEST_SECRET_KEY=minioadmin go test ./internal/storage
+func TestS3Store(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+	s, err := NewS3Store(context.Background(), S3Config{
+		Endpoint:  endpoint,
+		AccessKey: os.Getenv("S3_TEST_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_TEST_SECRET_KEY"),
+		Bucket:    "lesta-start-test",
+		Prefix:    "test",
+	})
+	require.NoError(t, err)
+	testBlobStore(t, s)
+}
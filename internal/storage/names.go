@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultFilename - имя документа, если после очистки от имени ничего не осталось
+const DefaultFilename = "document"
+
+// maxSegmentLength - максимальная длина одного сегмента имени в байтах
+const maxSegmentLength = 255
+
+// BlobKey - ключ хранения содержимого по его SHA-256: "sha256/ab/abcdef...".
+// Первые два символа хеша выделены в каталог, чтобы не держать все файлы в одном.
+func BlobKey(hash string) string {
+	return path.Join("sha256", hash[:2], hash)
+}
+
+// NewBlobKey - ключ файла для новой записи о содержимом: BlobKey со случайным суффиксом.
+// Запись, созданная заново после удаления прежней, не пишет в файл, который ещё может удаляться.
+func NewBlobKey(hash string) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return BlobKey(hash) + "-" + hex.EncodeToString(b)
+}
+
+// SanitizeFilename - отображаемое имя документа: без управляющих и зарезервированных символов,
+// без пустых сегментов и переходов "..". Относительные пути (файлы из архивов) сохраняются через "/".
+func SanitizeFilename(name string) string {
+	if name = CleanFilename(name); name == "" {
+		return DefaultFilename
+	}
+	return name
+}
+
+// CleanFilename - очистка имени как в SanitizeFilename, но без подстановки DefaultFilename:
+// пустая строка, если от имени ничего не осталось
+func CleanFilename(name string) string {
+	name = strings.ToValidUTF8(name, "")
+	name = strings.ReplaceAll(name, "\\", "/")
+
+	var parts []string
+	for _, part := range strings.Split(name, "/") {
+		part = strings.Map(func(r rune) rune {
+			switch {
+			case unicode.IsControl(r) || r == utf8.RuneError:
+				return -1
+			case strings.ContainsRune(`<>:"|?*`, r):
+				return '_'
+			}
+			return r
+		}, part)
+		part = strings.TrimRight(strings.TrimSpace(part), ". ")
+		if part == "" {
+			continue
+		}
+		parts = append(parts, truncate(part, maxSegmentLength))
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// NumberedFilename - вариант имени для разрешения конфликтов: "report.txt" -> "report (2).txt"
+func NumberedFilename(name string, n int) string {
+	dir, base := path.Split(name)
+	ext := path.Ext(base)
+	if ext == base {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(base, ext)
+	return dir + stem + " (" + strconv.Itoa(n) + ")" + ext
+}
+
+// truncate - обрезка строки до limit байт без разрыва UTF-8 последовательностей
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
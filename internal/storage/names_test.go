@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlobKey(t *testing.T) {
+	hash := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	assert.Equal(t, "sha256/9f/"+hash, BlobKey(hash))
+	assert.NoError(t, ValidKey(BlobKey(hash)))
+}
+
+func TestNewBlobKey(t *testing.T) {
+	hash := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	key := NewBlobKey(hash)
+	assert.True(t, strings.HasPrefix(key, BlobKey(hash)+"-"), key)
+	assert.NoError(t, ValidKey(key))
+	assert.NotEqual(t, key, NewBlobKey(hash))
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	cases := map[string]string{
+		"report.txt":               "report.txt",
+		"  отчёт 2024.docx  ":      "отчёт 2024.docx",
+		"../../etc/passwd":         "etc/passwd",
+		"C:\\Users\\me\\file.txt":  "C_/Users/me/file.txt",
+		"corpus/./sub//a.txt":      "corpus/sub/a.txt",
+		"bad\x00na\x1fme?.txt":     "badname_.txt",
+		"trailing dots...":         "trailing dots",
+		"":                         DefaultFilename,
+		"/../":                     DefaultFilename,
+		"a<b>c|d.txt":              "a_b_c_d.txt",
+		"\xff\xfeinvalid utf8.txt": "invalid utf8.txt",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, SanitizeFilename(in), in)
+	}
+
+	long := strings.Repeat("я", 200) + ".txt"
+	assert.LessOrEqual(t, len(SanitizeFilename(long)), maxSegmentLength)
+}
+
+func TestCleanFilename(t *testing.T) {
+	assert.Equal(t, "dir/a_b.txt", CleanFilename(" ../dir/a?b.txt "))
+	assert.Equal(t, "", CleanFilename(" /../. "))
+	assert.Equal(t, "", CleanFilename("\x00\x1f"))
+}
+
+func TestNumberedFilename(t *testing.T) {
+	assert.Equal(t, "report (1).txt", NumberedFilename("report.txt", 1))
+	assert.Equal(t, "dir/archive.tar (2).gz", NumberedFilename("dir/archive.tar.gz", 2))
+	assert.Equal(t, "README (3)", NumberedFilename("README", 3))
+	assert.Equal(t, ".env (1)", NumberedFilename(".env", 1))
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config - параметры подключения к S3-совместимому хранилищу (AWS S3, MinIO)
+type S3Config struct {
+	Endpoint  string // host:port без схемы
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Region    string
+	UseSSL    bool
+	Prefix    string // префикс ключей внутри бакета
+}
+
+// S3ConfigFromEnv - параметры из переменных окружения S3_*
+func S3ConfigFromEnv() S3Config {
+	return S3Config{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		Bucket:    envOr("S3_BUCKET", "documents"),
+		Region:    os.Getenv("S3_REGION"),
+		UseSSL:    os.Getenv("S3_USE_SSL") == "true",
+		Prefix:    strings.Trim(os.Getenv("S3_PREFIX"), "/"),
+	}
+}
+
+// S3Store - хранилище в бакете S3-совместимого сервиса
+type S3Store struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Store - подключение к хранилищу; бакет создаётся, если его ещё нет
+func NewS3Store(ctx context.Context, cfg S3Config) (*S3Store, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+			return nil, err
+		}
+	}
+	return &S3Store{client: client, bucket: cfg.Bucket, prefix: cfg.Prefix}, nil
+}
+
+func (s *S3Store) object(key string) (string, error) {
+	if err := ValidKey(key); err != nil {
+		return "", err
+	}
+	if s.prefix == "" {
+		return key, nil
+	}
+	return s.prefix + "/" + key, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	name, err := s.object(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, s.bucket, name, r, size, minio.PutObjectOptions{})
+	return err
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := s.object(key)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, translateS3Error(err)
+	}
+	// GetObject не обращается к серверу до первого чтения, поэтому отсутствие объекта проверяется через Stat
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, translateS3Error(err)
+	}
+	return obj, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	name, err := s.object(key)
+	if err != nil {
+		return err
+	}
+	return s.client.RemoveObject(ctx, s.bucket, name, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Store) Stat(ctx context.Context, key string) (BlobInfo, error) {
+	name, err := s.object(key)
+	if err != nil {
+		return BlobInfo{}, err
+	}
+	info, err := s.client.StatObject(ctx, s.bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		return BlobInfo{}, translateS3Error(err)
+	}
+	return BlobInfo{Key: key, Size: info.Size, ModTime: info.LastModified}, nil
+}
+
+func translateS3Error(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Параметры кодов, которые понимают все распространённые приложения-аутентификаторы
+const (
+	Digits     = 6
+	Period     = 30 * time.Second
+	Skew       = 1  // сколько соседних периодов принимается с каждой стороны из-за расхождения часов
+	SecretSize = 20 // байт секрета, как у выхода HMAC-SHA1 (RFC 4226)
+)
+
+var ErrInvalidSecret = errors.New("invalid TOTP secret")
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret - случайный секрет в base32 без выравнивания
+func NewSecret() (string, error) {
+	b := make([]byte, SecretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// DecodeSecret - секрет из base32; регистр, пробелы и выравнивание не важны
+func DecodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := encoding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return key, nil
+}
+
+// HOTP - одноразовый код по счётчику (RFC 4226)
+func HOTP(key []byte, counter uint64, digits int, h func() hash.Hash) string {
+	mac := hmac.New(h, key)
+	binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// Counter - номер периода, в который попадает t (RFC 6238, T0 = 0)
+func Counter(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code - код для момента t
+func Code(secret string, t time.Time) (string, error) {
+	key, err := DecodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return HOTP(key, uint64(Counter(t)), Digits, sha1.New), nil
+}
+
+// Validate - проверка кода для момента t с допуском Skew периодов.
+// Возвращает номер периода совпавшего кода: его сохраняют, чтобы не принять тот же код повторно.
+func Validate(secret, code string, t time.Time) (int64, bool) {
+	key, err := DecodeSecret(secret)
+	if err != nil || len(code) != Digits {
+		return 0, false
+	}
+	now := Counter(t)
+	for counter := now - Skew; counter <= now+Skew; counter++ {
+		expected := HOTP(key, uint64(counter), Digits, sha1.New)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// URI - адрес otpauth:// для добавления секрета в приложение-аутентификатор (обычно в виде QR-кода)
+func URI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(Digits))
+	q.Set("period", fmt.Sprint(int(Period/time.Second)))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
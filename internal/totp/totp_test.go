@@ -0,0 +1,117 @@
+package totp
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тестовые значения из приложения D RFC 4226
+func TestHOTP(t *testing.T) {
+	key := []byte("12345678901234567890")
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range expected {
+		assert.Equal(t, code, HOTP(key, uint64(counter), 6, sha1.New), "counter %d", counter)
+	}
+}
+
+// Тестовые значения из приложения B RFC 6238: 8 цифр, период 30 секунд, ключи разной длины для разных хэшей
+func TestRFC6238(t *testing.T) {
+	keys := map[string][]byte{
+		"SHA1":   []byte("12345678901234567890"),
+		"SHA256": []byte("12345678901234567890123456789012"),
+		"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	hashes := map[string]func() hash.Hash{"SHA1": sha1.New, "SHA256": sha256.New, "SHA512": sha512.New}
+	vectors := []struct {
+		unix int64
+		mode string
+		code string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1111111109, "SHA512", "25091201"},
+		{1111111111, "SHA1", "14050471"},
+		{1111111111, "SHA256", "67062674"},
+		{1111111111, "SHA512", "99943326"},
+		{1234567890, "SHA1", "89005924"},
+		{1234567890, "SHA256", "91819424"},
+		{1234567890, "SHA512", "93441116"},
+		{2000000000, "SHA1", "69279037"},
+		{2000000000, "SHA256", "90698825"},
+		{2000000000, "SHA512", "38618901"},
+		{20000000000, "SHA1", "65353130"},
+		{20000000000, "SHA256", "77737706"},
+		{20000000000, "SHA512", "47863826"},
+	}
+	for _, v := range vectors {
+		counter := uint64(Counter(time.Unix(v.unix, 0)))
+		assert.Equal(t, v.code, HOTP(keys[v.mode], counter, 8, hashes[v.mode]), "%s at %d", v.mode, v.unix)
+	}
+}
+
+func TestCodeAndValidate(t *testing.T) {
+	secret := encoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+
+	code, err := Code(secret, now)
+	require.NoError(t, err)
+	assert.Equal(t, "081804", code, "последние 6 цифр кода RFC 6238")
+
+	counter, ok := Validate(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, Counter(now), counter)
+
+	_, ok = Validate(secret, code, now.Add(Period))
+	assert.True(t, ok, "предыдущий период в пределах допуска")
+	_, ok = Validate(secret, code, now.Add(-Period))
+	assert.True(t, ok, "следующий период в пределах допуска")
+	_, ok = Validate(secret, code, now.Add(2*Period))
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "000000", now)
+	assert.False(t, ok)
+	_, ok = Validate(secret, "81804", now)
+	assert.False(t, ok)
+	_, ok = Validate("не base32", code, now)
+	assert.False(t, ok)
+}
+
+func TestSecret(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	key, err := DecodeSecret(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, SecretSize)
+
+	spaced, err := DecodeSecret("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("12345678901234567890"), spaced)
+
+	_, err = DecodeSecret("")
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Lesta Start", "bob", "GEZDGNBVGY3TQOJQ")
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Lesta Start:bob", u.Path)
+	assert.Equal(t, "GEZDGNBVGY3TQOJQ", u.Query().Get("secret"))
+	assert.Equal(t, "Lesta Start", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+}